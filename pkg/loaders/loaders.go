@@ -0,0 +1,184 @@
+// Package loaders runs data loaders for the layout chain returned by
+// matcher.FindNestedMatches. Each pattern may register a typed loader. When a
+// path is matched, every matched pattern's loader runs concurrently, and the
+// results come back aligned to the matches.
+//
+// A loader can short-circuit the whole chain by returning Redirect(...) or
+// ErrNotFound (optionally wrapped). Any other error cancels the remaining
+// loaders and is returned from Run.
+//
+// Usage:
+//
+//	l := loaders.New[any]()
+//	l.Register("/dashboard", loadDashboard)
+//	l.Register("/dashboard/:id", loadItem)
+//
+//	results, _ := m.FindNestedMatches(r.URL.Path)
+//	data, err := l.Run(r.Context(), results)
+package loaders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sjc5/kit/pkg/matcher"
+)
+
+// LoaderFunc loads the data for a single matched pattern.
+type LoaderFunc[T any] = func(ctx context.Context, match *matcher.Match) (T, error)
+
+// ErrNotFound may be returned (optionally wrapped) by a loader to signal that
+// the requested resource does not exist.
+var ErrNotFound = errors.New("loaders: not found")
+
+// RedirectError signals that the request should be redirected. Create one with
+// Redirect.
+type RedirectError struct {
+	URL  string
+	Code int
+}
+
+func (e *RedirectError) Error() string {
+	return fmt.Sprintf("loaders: redirect (%d) to %s", e.Code, e.URL)
+}
+
+// Redirect returns an error that, when returned by a loader, signals that the
+// request should be redirected. If code is zero, it defaults to 303 See Other.
+func Redirect(url string, code int) error {
+	if code == 0 {
+		code = http.StatusSeeOther
+	}
+	return &RedirectError{URL: url, Code: code}
+}
+
+type Loaders[T any] struct {
+	fns map[string]LoaderFunc[T] // original pattern -> loader
+}
+
+func New[T any]() *Loaders[T] {
+	return &Loaders[T]{fns: make(map[string]LoaderFunc[T])}
+}
+
+// Register registers a loader for a pattern. The pattern must be given exactly
+// as it was registered with the matcher. Register panics if a loader is
+// already registered for the pattern. Register must not be called
+// concurrently with Run.
+func (l *Loaders[T]) Register(pattern string, fn LoaderFunc[T]) {
+	if fn == nil {
+		panic("loaders: loader must not be nil")
+	}
+	if _, exists := l.fns[pattern]; exists {
+		panic("loaders: loader already registered for pattern " + pattern)
+	}
+	l.fns[pattern] = fn
+}
+
+// Has reports whether a loader is registered for the pattern.
+func (l *Loaders[T]) Has(pattern string) bool {
+	_, ok := l.fns[pattern]
+	return ok
+}
+
+type Results[T any] struct {
+	Matches []*matcher.Match
+
+	// Data is aligned to Matches. Matches without a loader (or whose loader
+	// never completed because of a signal) get the zero value of T.
+	Data []T
+
+	// Set if a loader signaled a redirect. If several loaders signal, the
+	// outermost one wins.
+	Redirect *RedirectError
+
+	// Set if a loader returned ErrNotFound. If several loaders signal, the
+	// outermost one wins.
+	NotFound bool
+
+	// Index into Matches of the loader that signaled, or -1 if none did.
+	SignalIndex int
+}
+
+// Run runs the loaders for every match concurrently. Once any loader fails
+// or signals, the context passed to the remaining loaders is canceled.
+//
+// If a loader signals a redirect or not found, Run returns results with the
+// signal set and a nil error. Otherwise, the first loader error to occur is
+// returned. A nil results value returns empty results.
+func (l *Loaders[T]) Run(ctx context.Context, results *matcher.FindNestedMatchesResults) (*Results[T], error) {
+	out := &Results[T]{SignalIndex: -1}
+	if results == nil || len(results.Matches) == 0 {
+		return out, nil
+	}
+
+	out.Matches = results.Matches
+	out.Data = make([]T, len(results.Matches))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]error, len(results.Matches))
+	firstErrIdx := atomic.Int64{}
+	firstErrIdx.Store(-1)
+
+	var wg sync.WaitGroup
+	for i, match := range results.Matches {
+		fn, ok := l.fns[match.OriginalPattern()]
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := runLoader(ctx, fn, match)
+			if err != nil {
+				errs[i] = err
+				firstErrIdx.CompareAndSwap(-1, int64(i))
+				cancel()
+				return
+			}
+			out.Data[i] = data
+		}()
+	}
+	wg.Wait()
+
+	// Signals take precedence over errors, which are often just the result
+	// of the signal having canceled the other loaders.
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		var redirect *RedirectError
+		if errors.As(err, &redirect) {
+			out.Redirect = redirect
+			out.SignalIndex = i
+			return out, nil
+		}
+		if errors.Is(err, ErrNotFound) {
+			out.NotFound = true
+			out.SignalIndex = i
+			return out, nil
+		}
+	}
+
+	if idx := firstErrIdx.Load(); idx != -1 {
+		return nil, fmt.Errorf(
+			"loaders: loader for pattern '%s' failed: %w",
+			results.Matches[idx].OriginalPattern(), errs[idx],
+		)
+	}
+
+	return out, nil
+}
+
+func runLoader[T any](ctx context.Context, fn LoaderFunc[T], match *matcher.Match) (data T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx, match)
+}
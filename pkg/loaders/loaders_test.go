@@ -0,0 +1,194 @@
+package loaders
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sjc5/kit/pkg/matcher"
+)
+
+func setup(t *testing.T, path string) *matcher.FindNestedMatchesResults {
+	t.Helper()
+	m := matcher.New(&matcher.Options{Quiet: true})
+	m.RegisterPattern("/dashboard")
+	m.RegisterPattern("/dashboard/:id")
+	m.RegisterPattern("/dashboard/:id/edit")
+	results, ok := m.FindNestedMatches(path)
+	if !ok {
+		t.Fatalf("FindNestedMatches(%q) found no matches", path)
+	}
+	return results
+}
+
+func TestRunAlignsResultsToMatches(t *testing.T) {
+	l := New[string]()
+	l.Register("/dashboard", func(ctx context.Context, match *matcher.Match) (string, error) {
+		return "layout", nil
+	})
+	l.Register("/dashboard/:id/edit", func(ctx context.Context, match *matcher.Match) (string, error) {
+		return "edit " + match.Params["id"], nil
+	})
+
+	out, err := l.Run(context.Background(), setup(t, "/dashboard/42/edit"))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	want := []string{"layout", "", "edit 42"}
+	if len(out.Data) != len(want) {
+		t.Fatalf("Run() returned %d results, want %d", len(out.Data), len(want))
+	}
+	for i := range want {
+		if out.Data[i] != want[i] {
+			t.Errorf("Data[%d] = %q, want %q", i, out.Data[i], want[i])
+		}
+	}
+	if out.SignalIndex != -1 || out.Redirect != nil || out.NotFound {
+		t.Errorf("Run() reported an unexpected signal: %+v", out)
+	}
+}
+
+func TestRunIsConcurrent(t *testing.T) {
+	var running atomic.Int32
+	var maxRunning atomic.Int32
+
+	loader := func(ctx context.Context, match *matcher.Match) (int, error) {
+		n := running.Add(1)
+		for {
+			current := maxRunning.Load()
+			if n <= current || maxRunning.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		running.Add(-1)
+		return 1, nil
+	}
+
+	l := New[int]()
+	l.Register("/dashboard", loader)
+	l.Register("/dashboard/:id", loader)
+	l.Register("/dashboard/:id/edit", loader)
+
+	if _, err := l.Run(context.Background(), setup(t, "/dashboard/1/edit")); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if maxRunning.Load() != 3 {
+		t.Errorf("max concurrent loaders = %d, want 3", maxRunning.Load())
+	}
+}
+
+func TestRunCancelsOnFirstError(t *testing.T) {
+	boom := errors.New("boom")
+
+	l := New[int]()
+	l.Register("/dashboard", func(ctx context.Context, match *matcher.Match) (int, error) {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(5 * time.Second):
+			return 1, nil
+		}
+	})
+	l.Register("/dashboard/:id", func(ctx context.Context, match *matcher.Match) (int, error) {
+		return 0, boom
+	})
+
+	start := time.Now()
+	_, err := l.Run(context.Background(), setup(t, "/dashboard/1"))
+	if !errors.Is(err, boom) {
+		t.Fatalf("Run() error = %v, want %v", err, boom)
+	}
+	if !strings.Contains(err.Error(), "/dashboard/:id") {
+		t.Errorf("Run() error %q should name the failing pattern", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Error("Run() did not cancel the slow loader")
+	}
+}
+
+func TestRunSignals(t *testing.T) {
+	t.Run("redirect", func(t *testing.T) {
+		l := New[int]()
+		l.Register("/dashboard", func(ctx context.Context, match *matcher.Match) (int, error) {
+			return 0, Redirect("/login", 0)
+		})
+		l.Register("/dashboard/:id", func(ctx context.Context, match *matcher.Match) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		})
+
+		out, err := l.Run(context.Background(), setup(t, "/dashboard/1"))
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if out.Redirect == nil || out.Redirect.URL != "/login" || out.Redirect.Code != http.StatusSeeOther {
+			t.Errorf("Redirect = %+v, want /login with 303", out.Redirect)
+		}
+		if out.SignalIndex != 0 {
+			t.Errorf("SignalIndex = %d, want 0", out.SignalIndex)
+		}
+	})
+
+	t.Run("wrapped not found", func(t *testing.T) {
+		l := New[int]()
+		l.Register("/dashboard/:id", func(ctx context.Context, match *matcher.Match) (int, error) {
+			return 0, errors.Join(errors.New("no such item"), ErrNotFound)
+		})
+
+		out, err := l.Run(context.Background(), setup(t, "/dashboard/1"))
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if !out.NotFound || out.SignalIndex != 1 {
+			t.Errorf("NotFound = %v, SignalIndex = %d, want true, 1", out.NotFound, out.SignalIndex)
+		}
+	})
+
+	t.Run("outermost signal wins", func(t *testing.T) {
+		l := New[int]()
+		l.Register("/dashboard", func(ctx context.Context, match *matcher.Match) (int, error) {
+			time.Sleep(10 * time.Millisecond)
+			return 0, ErrNotFound
+		})
+		l.Register("/dashboard/:id", func(ctx context.Context, match *matcher.Match) (int, error) {
+			return 0, Redirect("/elsewhere", http.StatusFound)
+		})
+
+		out, err := l.Run(context.Background(), setup(t, "/dashboard/1"))
+		if err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+		if !out.NotFound || out.Redirect != nil || out.SignalIndex != 0 {
+			t.Errorf("got %+v, want not found from the outermost loader", out)
+		}
+	})
+}
+
+func TestRunRecoversPanics(t *testing.T) {
+	l := New[int]()
+	l.Register("/dashboard", func(ctx context.Context, match *matcher.Match) (int, error) {
+		panic("oops")
+	})
+
+	if _, err := l.Run(context.Background(), setup(t, "/dashboard")); err == nil {
+		t.Error("Run() should return an error when a loader panics")
+	}
+}
+
+func TestRegisterDuplicatePanics(t *testing.T) {
+	l := New[int]()
+	l.Register("/dashboard", func(ctx context.Context, match *matcher.Match) (int, error) { return 0, nil })
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() should panic on a duplicate pattern")
+		}
+	}()
+	l.Register("/dashboard", func(ctx context.Context, match *matcher.Match) (int, error) { return 0, nil })
+}
@@ -0,0 +1,68 @@
+package matcher
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// A Matcher is not safe for concurrent registration and lookup. AtomicMatcher
+// wraps one so that route tables can be rebuilt or edited while lookups are
+// running on other goroutines. Lookups always see either the complete old
+// table or the complete new table, never a half-built one.
+type AtomicMatcher struct {
+	current  atomic.Pointer[Matcher]
+	updateMu sync.Mutex
+}
+
+func NewAtomic(m *Matcher) *AtomicMatcher {
+	if m == nil {
+		panic("matcher must not be nil")
+	}
+	a := new(AtomicMatcher)
+	a.current.Store(m)
+	return a
+}
+
+// Load returns the current matcher. The returned matcher must be treated as
+// read-only.
+func (a *AtomicMatcher) Load() *Matcher {
+	return a.current.Load()
+}
+
+// Swap replaces the current matcher with a fully built one and returns the
+// previous matcher. After passing m to Swap, callers must not modify it.
+func (a *AtomicMatcher) Swap(m *Matcher) *Matcher {
+	if m == nil {
+		panic("matcher must not be nil")
+	}
+	a.updateMu.Lock()
+	defer a.updateMu.Unlock()
+	return a.current.Swap(m)
+}
+
+// Update applies fn to a copy of the current matcher and then swaps the copy
+// in. Concurrent calls to Update are serialized, so no edits are lost.
+func (a *AtomicMatcher) Update(fn func(m *Matcher)) {
+	a.updateMu.Lock()
+	defer a.updateMu.Unlock()
+
+	next := a.current.Load().Clone()
+	fn(next)
+	a.current.Store(next)
+}
+
+func (a *AtomicMatcher) FindBestMatch(realPath string) (*Match, bool) {
+	return a.current.Load().FindBestMatch(realPath)
+}
+
+func (a *AtomicMatcher) FindNestedMatches(realPath string) (*FindNestedMatchesResults, bool) {
+	return a.current.Load().FindNestedMatches(realPath)
+}
+
+func (a *AtomicMatcher) FindBestMatchForHost(host, realPath string) (*Match, bool) {
+	return a.current.Load().FindBestMatchForHost(host, realPath)
+}
+
+func (a *AtomicMatcher) FindNestedMatchesForHost(host, realPath string) (*FindNestedMatchesResults, bool) {
+	return a.current.Load().FindNestedMatchesForHost(host, realPath)
+}
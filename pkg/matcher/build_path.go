@@ -0,0 +1,207 @@
+package matcher
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// BuildPath builds a concrete path from a registered pattern, the values for
+// its dynamic params, and the values for its splat segment (if any). The
+// pattern may be given either as it was originally registered or in its
+// normalized form. Param and splat values are path-escaped.
+//
+// For patterns with mid-path wildcards or globstars, splat values are consumed
+// in order, one per wildcard. A globstar consumes every value not needed by
+// the wildcards after it (possibly none).
+//
+// For patterns with optional segments, the expanded form whose dynamic params
+// exactly match the supplied params is used. To choose a particular
+// alternative of an alternation group, pass the normalized form instead
+// (e.g., "/shop/fr/cart").
+//
+// An error is returned if the pattern is unknown, if a dynamic param is
+// missing, empty, or fails its constraint, if an unexpected param is
+// supplied, if splat values are supplied for a pattern without a splat, or if
+// the number of splat values doesn't fit the pattern's wildcards.
+//
+// For host-qualified patterns, only the path is built. Params belonging to
+// the host part are accepted and ignored.
+func (m *Matcher) BuildPath(pattern string, params Params, splat []string) (string, error) {
+	if host, _, ok := SplitHostPattern(pattern); ok {
+		return m.buildHostPath(pattern, host, params, splat)
+	}
+	return m.buildPath(pattern, pattern, params, splat)
+}
+
+// buildPath builds a path for a pattern registered directly on m. The display
+// pattern is only used in error messages.
+func (m *Matcher) buildPath(pattern, displayPattern string, params Params, splat []string) (string, error) {
+	rp, err := m.findRegisteredPatternForBuild(pattern, params)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	var usedParams int
+	var hasSplat bool
+	splatIdx := 0
+
+	for i, seg := range rp.normalizedSegments {
+		switch seg.segType {
+		case segTypes.static:
+			sb.WriteString("/")
+			sb.WriteString(seg.literal)
+
+		case segTypes.index:
+			sb.WriteString("/")
+
+		case segTypes.dynamic:
+			val, ok := params[seg.paramName]
+			if !ok || val == "" {
+				return "", fmt.Errorf("matcher.BuildPath: missing value for param '%s' in pattern '%s'", seg.paramName, displayPattern)
+			}
+			if !seg.constraint.allows(val) {
+				return "", fmt.Errorf("matcher.BuildPath: value '%s' for param '%s' does not satisfy constraint '%s'", val, seg.paramName, seg.constraint.raw)
+			}
+			usedParams++
+			sb.WriteString("/")
+			sb.WriteString(seg.prefix)
+			sb.WriteString(url.PathEscape(val))
+			sb.WriteString(seg.suffix)
+
+		case segTypes.wildcard:
+			hasSplat = true
+			if splatIdx >= len(splat) || splat[splatIdx] == "" {
+				return "", fmt.Errorf("matcher.BuildPath: missing splat value for wildcard segment '%s' in pattern '%s'", seg.normalizedVal, displayPattern)
+			}
+			sb.WriteString("/")
+			sb.WriteString(seg.prefix)
+			sb.WriteString(url.PathEscape(splat[splatIdx]))
+			sb.WriteString(seg.suffix)
+			splatIdx++
+
+		case segTypes.globstar:
+			hasSplat = true
+			n := len(splat) - splatIdx - countWildcards(rp.normalizedSegments[i+1:])
+			for range max(n, 0) {
+				sb.WriteString("/")
+				sb.WriteString(url.PathEscape(splat[splatIdx]))
+				splatIdx++
+			}
+
+		case segTypes.splat:
+			hasSplat = true
+			sb.WriteString("/")
+			for i, s := range splat[min(splatIdx, len(splat)):] {
+				if i > 0 {
+					sb.WriteString("/")
+				}
+				sb.WriteString(url.PathEscape(s))
+			}
+			splatIdx = len(splat)
+		}
+	}
+
+	if usedParams != len(params) {
+		for name := range params {
+			if !slices.ContainsFunc(rp.normalizedSegments, func(seg *segment) bool {
+				return seg.segType == segTypes.dynamic && seg.paramName == name
+			}) {
+				return "", fmt.Errorf("matcher.BuildPath: unexpected param '%s' for pattern '%s'", name, displayPattern)
+			}
+		}
+	}
+
+	if !hasSplat && len(splat) > 0 {
+		return "", fmt.Errorf("matcher.BuildPath: splat values supplied for pattern '%s', which has no splat segment", displayPattern)
+	}
+	if splatIdx < len(splat) {
+		return "", fmt.Errorf("matcher.BuildPath: too many splat values supplied for pattern '%s'", displayPattern)
+	}
+
+	if sb.Len() == 0 {
+		return "/", nil
+	}
+
+	return sb.String(), nil
+}
+
+func countWildcards(segments []*segment) int {
+	var n int
+	for _, seg := range segments {
+		if seg.segType == segTypes.wildcard {
+			n++
+		}
+	}
+	return n
+}
+
+func (m *Matcher) findRegisteredPatternForBuild(pattern string, params Params) (*RegisteredPattern, error) {
+	if rp, ok := m.staticPatterns[pattern]; ok {
+		return rp, nil
+	}
+	if rp, ok := m.dynamicPatterns[pattern]; ok {
+		return rp, nil
+	}
+
+	rps, ok := m.originalPatterns[pattern]
+	if !ok || len(rps) == 0 {
+		return nil, fmt.Errorf("matcher.BuildPath: unknown pattern '%s'", pattern)
+	}
+	if len(rps) == 1 {
+		return rps[0], nil
+	}
+
+	// Expanded forms are ordered with the fullest form first, so the first
+	// form whose dynamic params exactly match the supplied params wins.
+	for _, rp := range rps {
+		if int(rp.numberOfDynamicParamSegs) != len(params) {
+			continue
+		}
+		allSupplied := true
+		for _, seg := range rp.normalizedSegments {
+			if seg.segType == segTypes.dynamic {
+				if _, ok := params[seg.paramName]; !ok {
+					allSupplied = false
+					break
+				}
+			}
+		}
+		if allSupplied {
+			return rp, nil
+		}
+	}
+
+	return nil, fmt.Errorf("matcher.BuildPath: no form of pattern '%s' accepts the supplied params", pattern)
+}
+
+func (m *Matcher) buildHostPath(pattern, host string, params Params, splat []string) (string, error) {
+	labels := m.parseHost(host)
+	hr, ok := m.hostRoutesByHost[joinLabels(labels)]
+	if !ok {
+		return "", fmt.Errorf("matcher.BuildPath: unknown pattern '%s'", pattern)
+	}
+
+	var pathParams Params
+	for name, val := range params {
+		if !slices.ContainsFunc(labels, func(label *segment) bool {
+			return label.segType == segTypes.dynamic && label.paramName == name
+		}) {
+			if pathParams == nil {
+				pathParams = make(Params, len(params))
+			}
+			pathParams[name] = val
+		}
+	}
+
+	// The host route's matcher knows the pattern by its original form, and
+	// its normalized forms by their path alone
+	lookup := pattern
+	if _, ok := hr.matcher.originalPatterns[pattern]; !ok {
+		_, lookup, _ = SplitHostPattern(pattern)
+	}
+
+	return hr.matcher.buildPath(lookup, pattern, pathParams, splat)
+}
@@ -0,0 +1,83 @@
+package matcher
+
+import "testing"
+
+func TestBuildPath(t *testing.T) {
+	m := New(&Options{Quiet: true, ExplicitIndexSegment: "_index"})
+	m.RegisterPattern("/_index")
+	m.RegisterPattern("/about")
+	m.RegisterPattern("/users/:id<int>")
+	m.RegisterPattern("/users/:id/_index")
+	m.RegisterPattern("/posts/:slug")
+	m.RegisterPattern("/files/*")
+	m.RegisterPattern("/docs/:version?/:page?")
+	m.RegisterPattern("/shop/(en|fr)?/cart")
+
+	tests := []struct {
+		name    string
+		pattern string
+		params  Params
+		splat   []string
+		want    string
+		wantErr bool
+	}{
+		{name: "root index", pattern: "/_index", want: "/"},
+		{name: "static", pattern: "/about", want: "/about"},
+		{name: "dynamic by original", pattern: "/users/:id<int>", params: Params{"id": "42"}, want: "/users/42"},
+		{name: "index by original", pattern: "/users/:id/_index", params: Params{"id": "42"}, want: "/users/42/"},
+		{name: "index by normalized", pattern: "/users/:id/", params: Params{"id": "42"}, want: "/users/42/"},
+		{name: "escapes params", pattern: "/posts/:slug", params: Params{"slug": "a b/c?d"}, want: "/posts/a%20b%2Fc%3Fd"},
+		{name: "splat", pattern: "/files/*", splat: []string{"a", "b c", "d.txt"}, want: "/files/a/b%20c/d.txt"},
+		{name: "empty splat", pattern: "/files/*", want: "/files/"},
+		{name: "optional all present", pattern: "/docs/:version?/:page?", params: Params{"version": "v1", "page": "intro"}, want: "/docs/v1/intro"},
+		{name: "optional first present", pattern: "/docs/:version?/:page?", params: Params{"version": "v1"}, want: "/docs/v1"},
+		{name: "optional none present", pattern: "/docs/:version?/:page?", want: "/docs"},
+		{name: "alternation by original picks fullest form", pattern: "/shop/(en|fr)?/cart", want: "/shop/en/cart"},
+		{name: "alternation by normalized", pattern: "/shop/fr/cart", want: "/shop/fr/cart"},
+
+		{name: "unknown pattern", pattern: "/nope", wantErr: true},
+		{name: "missing param", pattern: "/posts/:slug", wantErr: true},
+		{name: "empty param", pattern: "/posts/:slug", params: Params{"slug": ""}, wantErr: true},
+		{name: "unexpected param", pattern: "/posts/:slug", params: Params{"slug": "a", "extra": "b"}, wantErr: true},
+		{name: "failed constraint", pattern: "/users/:id<int>", params: Params{"id": "abc"}, wantErr: true},
+		{name: "splat on non-splat pattern", pattern: "/about", splat: []string{"x"}, wantErr: true},
+		{name: "no matching optional form", pattern: "/docs/:version?/:page?", params: Params{"other": "x"}, wantErr: true},
+		{name: "optional later present without earlier", pattern: "/docs/:version?/:page?", params: Params{"page": "intro"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.BuildPath(tt.pattern, tt.params, tt.splat)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("BuildPath() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BuildPath() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("BuildPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildPathRoundTrip(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	m.RegisterPattern("/api/:version/users/:id")
+
+	path, err := m.BuildPath("/api/:version/users/:id", Params{"version": "v2", "id": "123"}, nil)
+	if err != nil {
+		t.Fatalf("BuildPath() error = %v", err)
+	}
+
+	match, ok := m.FindBestMatch(path)
+	if !ok {
+		t.Fatalf("FindBestMatch(%q) found no match", path)
+	}
+	if !equalParams(match.Params, Params{"version": "v2", "id": "123"}) {
+		t.Errorf("FindBestMatch(%q) params = %v", path, match.Params)
+	}
+}
@@ -0,0 +1,115 @@
+package matcher
+
+import "strings"
+
+// TrailingSlashPolicy controls how FindBestMatch and FindNestedMatches treat
+// a trailing slash that the matched pattern doesn't have (e.g., "/about/" for pattern "/about").
+type TrailingSlashPolicy uint8
+
+const (
+	// TrailingSlashLenient matches with or without the trailing slash. This is
+	// the default.
+	TrailingSlashLenient TrailingSlashPolicy = iota + 1
+
+	// TrailingSlashStrict only matches if the trailing slash (or lack thereof)
+	// agrees with the pattern. Index patterns (e.g., "/users/") still require
+	// their trailing slash.
+	TrailingSlashStrict
+
+	// TrailingSlashRedirect matches leniently, but signals (e.g., to the
+	// router) that requests for non-canonical paths should be redirected to
+	// the path returned by CanonicalPath.
+	TrailingSlashRedirect
+)
+
+// TrailingSlashPolicy returns the matcher's trailing slash policy.
+func (m *Matcher) TrailingSlashPolicy() TrailingSlashPolicy {
+	return m.trailingSlash
+}
+
+// CleanPath returns the shortest path equivalent to p, by collapsing
+// duplicate slashes and resolving "." and ".." segments. Unlike path.Clean,
+// a trailing slash is preserved, and the result always starts with a slash.
+func CleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	if !needsCleaning(p) {
+		return p
+	}
+
+	hasTrailingSlash := p[len(p)-1] == '/'
+
+	parts := strings.Split(p, "/")
+	kept := make([]string, 0, len(parts))
+	for i, part := range parts {
+		switch part {
+		case "":
+			continue
+		case ".":
+			if i == len(parts)-1 {
+				hasTrailingSlash = true
+			}
+		case "..":
+			if len(kept) > 0 {
+				kept = kept[:len(kept)-1]
+			}
+			if i == len(parts)-1 {
+				hasTrailingSlash = true
+			}
+		default:
+			kept = append(kept, part)
+		}
+	}
+
+	cleaned := "/" + strings.Join(kept, "/")
+	if hasTrailingSlash && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+func needsCleaning(p string) bool {
+	if p[0] != '/' {
+		return true
+	}
+	for i := 1; i < len(p); i++ {
+		if p[i] == '/' && p[i-1] == '/' {
+			return true
+		}
+		if p[i] == '.' && p[i-1] == '/' {
+			rest := p[i:]
+			if rest == "." || rest == ".." || strings.HasPrefix(rest, "./") || strings.HasPrefix(rest, "../") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CanonicalPath returns the canonical form of realPath, given the match found
+// for it: the cleaned path (see CleanPath), with a trailing slash if and only
+// if the matched pattern is an index pattern. For patterns ending in a splat,
+// the trailing slash is left as is.
+func CanonicalPath(match *Match, realPath string) string {
+	cleaned := CleanPath(realPath)
+	if match == nil || match.RegisteredPattern == nil || cleaned == "/" {
+		return cleaned
+	}
+
+	hasTrailingSlash := cleaned[len(cleaned)-1] == '/'
+
+	switch {
+	case match.lastSegIsIndex:
+		if !hasTrailingSlash {
+			return cleaned + "/"
+		}
+	case match.lastSegType == segTypes.splat || match.lastSegType == segTypes.globstar:
+	default:
+		if hasTrailingSlash {
+			return cleaned[:len(cleaned)-1]
+		}
+	}
+
+	return cleaned
+}
@@ -0,0 +1,137 @@
+package matcher
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCleanPath(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", "/"},
+		{"/", "/"},
+		{"/users", "/users"},
+		{"/users/", "/users/"},
+		{"users", "/users"},
+		{"//users//42", "/users/42"},
+		{"/users//", "/users/"},
+		{"/users/./42", "/users/42"},
+		{"/users/42/..", "/users/"},
+		{"/users/42/../43", "/users/43"},
+		{"/../users", "/users"},
+		{"/users/.", "/users/"},
+		{"/.well-known/x", "/.well-known/x"},
+		{"/a/..b", "/a/..b"},
+	}
+
+	for _, tt := range tests {
+		if got := CleanPath(tt.in); got != tt.want {
+			t.Errorf("CleanPath(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCanonicalPath(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	m.RegisterPattern("/about")
+	m.RegisterPattern("/users/")
+	m.RegisterPattern("/users/:id")
+	m.RegisterPattern("/files/*")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/about", "/about"},
+		{"/about/", "/about"},
+		{"//about", "/about"},
+		{"/users/", "/users/"},
+		{"/users/42/", "/users/42"},
+		{"/users/./42", "/users/42"},
+		{"/files/a/", "/files/a/"},
+		{"/files//a", "/files/a"},
+	}
+
+	for _, tt := range tests {
+		match, ok := m.FindBestMatch(CleanPath(tt.path))
+		if !ok {
+			t.Fatalf("FindBestMatch(%q) found no match", tt.path)
+		}
+		if got := CanonicalPath(match, tt.path); got != tt.want {
+			t.Errorf("CanonicalPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestTrailingSlashPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      TrailingSlashPolicy
+		path        string
+		wantPattern string
+		wantNested  []string // nil if FindNestedMatches should find nothing
+	}{
+		{"lenient static", TrailingSlashLenient, "/about/", "/about", []string{"/about"}},
+		{"lenient dynamic", TrailingSlashLenient, "/users/42/", "/users/:id", []string{"/users/:id"}},
+		{"strict static", TrailingSlashStrict, "/about/", NOT_FOUND, nil},
+		{"strict dynamic", TrailingSlashStrict, "/users/42/", NOT_FOUND, nil},
+		{"strict exact", TrailingSlashStrict, "/users/42", "/users/:id", []string{"/users/:id"}},
+		{"strict index", TrailingSlashStrict, "/users/", "/users/", []string{"/users/"}},
+		{"strict splat", TrailingSlashStrict, "/files/a/", "/files/*", []string{"/files/*"}},
+		{"redirect matches leniently", TrailingSlashRedirect, "/about/", "/about", []string{"/about"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(&Options{Quiet: true, TrailingSlash: tt.policy})
+			m.RegisterPattern("/about")
+			m.RegisterPattern("/users/")
+			m.RegisterPattern("/users/:id")
+			m.RegisterPattern("/files/*")
+
+			match, ok := m.FindBestMatch(tt.path)
+			compiled, compiledOK := m.Compile().FindBestMatch(tt.path)
+			defer compiled.Release()
+
+			if ok != compiledOK {
+				t.Errorf("compiled ok = %v, uncompiled ok = %v", compiledOK, ok)
+			}
+
+			var nested, compiledNested []string
+			if results, ok := m.FindNestedMatches(tt.path); ok {
+				for _, match := range results.Matches {
+					nested = append(nested, match.normalizedPattern)
+				}
+			}
+			if results, ok := m.Compile().FindNestedMatches(tt.path); ok {
+				for _, match := range results.Matches {
+					compiledNested = append(compiledNested, match.normalizedPattern)
+				}
+				results.Release()
+			}
+			if !reflect.DeepEqual(nested, tt.wantNested) {
+				t.Errorf("FindNestedMatches(%q) = %v, want %v", tt.path, nested, tt.wantNested)
+			}
+			if !reflect.DeepEqual(compiledNested, tt.wantNested) {
+				t.Errorf("compiled FindNestedMatches(%q) = %v, want %v", tt.path, compiledNested, tt.wantNested)
+			}
+			if explained := m.Explain(tt.path).Nested; (explained != nil) != (tt.wantNested != nil) {
+				t.Errorf("Explain(%q).Nested = %v, want %v", tt.path, explained, tt.wantNested)
+			}
+
+			if tt.wantPattern == NOT_FOUND {
+				if ok {
+					t.Errorf("FindBestMatch(%q) = %q, want no match", tt.path, match.normalizedPattern)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("FindBestMatch(%q) found no match, want %q", tt.path, tt.wantPattern)
+			}
+			if match.normalizedPattern != tt.wantPattern {
+				t.Errorf("FindBestMatch(%q) = %q, want %q", tt.path, match.normalizedPattern, tt.wantPattern)
+			}
+		})
+	}
+}
@@ -0,0 +1,507 @@
+package matcher
+
+import (
+	"sync"
+)
+
+// CompiledMatcher is a frozen snapshot of a Matcher's route table, optimized
+// for lookups. Instead of splitting real paths into segment slices, it walks
+// them in place, and it reuses pooled match and param storage. Once warmed up,
+// best-match lookups allocate nothing for static routes, and at most once for
+// dynamic routes.
+//
+// Results must be released with Release once the caller is done with them,
+// after which their Params, SplatValues, and Matches must no longer be used.
+//
+// Lookups on matchers that use any of the CaseInsensitive,
+// DecodePercentEncoding, or NormalizeUnicode options, or that have patterns
+// with wildcards, globstars, or affix segments, are supported, but fall back to
+// the uncompiled algorithms (and allocate accordingly). Host-qualified patterns
+// are not part of the compiled route table.
+//
+// A CompiledMatcher is safe for concurrent use.
+type CompiledMatcher struct {
+	m        *Matcher // private clone, never modified
+	fallback bool
+
+	bestPool   sync.Pool
+	nestedPool sync.Pool
+}
+
+// CompiledMatch is the result of CompiledMatcher.FindBestMatch.
+type CompiledMatch struct {
+	Match
+
+	c      *CompiledMatcher
+	params Params   // pooled storage for Match.Params
+	splat  []string // pooled storage for Match.SplatValues
+}
+
+// CompiledNestedMatches is the result of CompiledMatcher.FindNestedMatches.
+type CompiledNestedMatches struct {
+	FindNestedMatchesResults
+
+	c       *CompiledMatcher
+	matches matchesMap
+	pool    []*Match // reusable matches, each owning the params map at the same index in ownPar
+	ownPar  []Params
+	used    int
+	params  Params   // working params during the walk
+	splat   []string // arena for the SplatValues of every match
+	results []*Match
+	buf     []byte // scratch space for building lookup keys
+}
+
+// Compile returns a frozen snapshot of the matcher's current route table.
+// Patterns registered or unregistered afterward do not affect the snapshot.
+func (m *Matcher) Compile() *CompiledMatcher {
+	c := &CompiledMatcher{m: m.Clone()}
+
+	c.fallback = m.foldsStatic || m.normalizesRealPaths
+	for _, rp := range m.dynamicPatterns {
+		if rp.hasComplexSegments {
+			c.fallback = true
+			break
+		}
+	}
+
+	c.bestPool.New = func() any {
+		return &CompiledMatch{params: make(Params)}
+	}
+	c.nestedPool.New = func() any {
+		return &CompiledNestedMatches{matches: make(matchesMap), params: make(Params)}
+	}
+
+	return c
+}
+
+// nextSegment returns the segment of path starting at pos and the position
+// of the segment after it. Segments are produced exactly as ParseSegments
+// would produce them. Returns false once the path is exhausted.
+func nextSegment(path string, pos int) (seg string, next int, ok bool) {
+	for pos < len(path) && path[pos] == '/' {
+		pos++
+	}
+	if pos < len(path) {
+		end := pos
+		for end < len(path) && path[end] != '/' {
+			end++
+		}
+		return path[pos:end], end, true
+	}
+	// Trailing slash
+	if pos == len(path) && len(path) > 0 && path[len(path)-1] == '/' {
+		return "", pos + 1, true
+	}
+	return "", pos, false
+}
+
+func isExhausted(path string, pos int) bool {
+	_, _, ok := nextSegment(path, pos)
+	return !ok
+}
+
+/////////////////////////////////////////////////////////////////////
+/////// BEST MATCH
+/////////////////////////////////////////////////////////////////////
+
+func (c *CompiledMatcher) getMatch() *CompiledMatch {
+	cm := c.bestPool.Get().(*CompiledMatch)
+	cm.c = c
+	return cm
+}
+
+// Release returns the match's storage to its pool. It is safe to call
+// Release more than once.
+func (cm *CompiledMatch) Release() {
+	if cm == nil || cm.c == nil {
+		return
+	}
+	c := cm.c
+	cm.c = nil
+	cm.Match = Match{}
+	clear(cm.params)
+	clear(cm.splat)
+	cm.splat = cm.splat[:0]
+	c.bestPool.Put(cm)
+}
+
+// FindBestMatch is like Matcher.FindBestMatch, but returns pooled storage
+// that must be released with Release.
+func (c *CompiledMatcher) FindBestMatch(realPath string) (*CompiledMatch, bool) {
+	m := c.m
+
+	if c.fallback {
+		match, ok := m.FindBestMatch(realPath)
+		if !ok {
+			return nil, false
+		}
+		cm := c.getMatch()
+		cm.Match = *match
+		return cm, true
+	}
+
+	if rp, ok := m.staticPatterns[realPath]; ok {
+		cm := c.getMatch()
+		cm.RegisteredPattern = rp
+		return cm, true
+	}
+
+	hasTrailingSlash := len(realPath) > 0 && realPath[len(realPath)-1] == '/' &&
+		m.trailingSlash != TrailingSlashStrict
+
+	if hasTrailingSlash {
+		if rp, ok := m.staticPatterns[realPath[:len(realPath)-1]]; ok {
+			cm := c.getMatch()
+			cm.RegisteredPattern = rp
+			return cm, true
+		}
+	}
+
+	var best *RegisteredPattern
+	var bestScore uint16
+	var foundMatch bool
+
+	c.dfsBest(m.rootNode, realPath, 0, 0, &best, &bestScore, &foundMatch, hasTrailingSlash)
+
+	if !foundMatch {
+		return nil, false
+	}
+
+	cm := c.getMatch()
+	cm.RegisteredPattern = best
+	cm.score = bestScore
+
+	hasSplat := best.normalizedPattern == "/*" || best.lastSegIsNonRootSplat
+	if best.numberOfDynamicParamSegs == 0 && !hasSplat {
+		return cm, true
+	}
+
+	pos := 0
+	for _, seg := range best.normalizedSegments {
+		if seg.segType == segTypes.splat {
+			for {
+				val, next, ok := nextSegment(realPath, pos)
+				if !ok {
+					break
+				}
+				cm.splat = append(cm.splat, val)
+				pos = next
+			}
+			break
+		}
+		val, next, _ := nextSegment(realPath, pos)
+		if seg.segType == segTypes.dynamic {
+			cm.params[seg.paramName] = val
+		}
+		pos = next
+	}
+
+	if best.numberOfDynamicParamSegs > 0 {
+		cm.Params = cm.params
+	}
+	if hasSplat {
+		cm.SplatValues = cm.splat
+	}
+
+	return cm, true
+}
+
+func (c *CompiledMatcher) dfsBest(
+	node *segmentNode,
+	path string,
+	pos int,
+	score uint16,
+	best **RegisteredPattern,
+	bestScore *uint16,
+	foundMatch *bool,
+	checkTrailingSlash bool,
+) {
+	seg, next, ok := nextSegment(path, pos)
+
+	// An empty segment can only ever be the trailing one
+	atNormalEnd := checkTrailingSlash && ok && seg == ""
+
+	if len(node.pattern) > 0 {
+		if rp, found := c.m.dynamicPatterns[node.pattern]; found {
+			if !ok || node.nodeType == nodeSplat || atNormalEnd {
+				if !*foundMatch || score > *bestScore {
+					*best = rp
+					*bestScore = score
+					*foundMatch = true
+				}
+			}
+		}
+	}
+
+	if !ok {
+		return
+	}
+
+	if node.children != nil {
+		if child, found := node.children[seg]; found {
+			c.dfsBest(child, path, next, score+scoreStaticMatch, best, bestScore, foundMatch, checkTrailingSlash)
+
+			if *foundMatch && child.pattern != "" && isExhausted(path, next) {
+				return
+			}
+		}
+	}
+
+	for _, child := range node.dynChildren {
+		switch child.nodeType {
+		case nodeDynamic:
+			if seg == "" || !child.constraint.allows(seg) {
+				continue
+			}
+			c.dfsBest(child, path, next, score+uint16(child.segScore), best, bestScore, foundMatch, checkTrailingSlash)
+
+		case nodeSplat:
+			if len(child.pattern) > 0 {
+				if rp := c.m.dynamicPatterns[child.pattern]; rp != nil {
+					if !*foundMatch {
+						*best = rp
+						*foundMatch = true
+					}
+				}
+			}
+		}
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+/////// NESTED MATCHES
+/////////////////////////////////////////////////////////////////////
+
+func (c *CompiledMatcher) getNested() *CompiledNestedMatches {
+	r := c.nestedPool.Get().(*CompiledNestedMatches)
+	r.c = c
+	return r
+}
+
+// Release returns the results' storage to their pool. It is safe to call
+// Release more than once.
+func (r *CompiledNestedMatches) Release() {
+	if r == nil || r.c == nil {
+		return
+	}
+	c := r.c
+	r.c = nil
+	r.FindNestedMatchesResults = FindNestedMatchesResults{}
+	clear(r.matches)
+	for i := range r.used {
+		r.pool[i].RegisteredPattern = nil
+		r.pool[i].SplatValues = nil
+		clear(r.ownPar[i])
+	}
+	r.used = 0
+	clear(r.params)
+	clear(r.splat)
+	r.splat = r.splat[:0]
+	clear(r.results)
+	r.results = r.results[:0]
+	c.nestedPool.Put(r)
+}
+
+// newMatch returns a reusable match with empty params.
+func (r *CompiledNestedMatches) newMatch(rp *RegisteredPattern) *Match {
+	if r.used == len(r.pool) {
+		params := make(Params)
+		r.pool = append(r.pool, &Match{Params: params})
+		r.ownPar = append(r.ownPar, params)
+	}
+	match := r.pool[r.used]
+	match.RegisteredPattern = rp
+	match.Params = r.ownPar[r.used]
+	r.used++
+	return match
+}
+
+// appendSplat appends the remaining segments of path, starting at pos, to
+// the splat arena and returns them.
+func (r *CompiledNestedMatches) appendSplat(path string, pos int) []string {
+	start := len(r.splat)
+	for {
+		val, next, ok := nextSegment(path, pos)
+		if !ok {
+			break
+		}
+		r.splat = append(r.splat, val)
+		pos = next
+	}
+	return r.splat[start:len(r.splat):len(r.splat)]
+}
+
+func (r *CompiledNestedMatches) findStatic(key []byte) (*RegisteredPattern, bool) {
+	rp, ok := r.c.m.staticPatterns[string(key)]
+	return rp, ok
+}
+
+// FindNestedMatches is like Matcher.FindNestedMatches, but returns pooled
+// storage that must be released with Release.
+func (c *CompiledMatcher) FindNestedMatches(realPath string) (*CompiledNestedMatches, bool) {
+	m := c.m
+
+	if c.fallback {
+		results, ok := m.FindNestedMatches(realPath)
+		if !ok {
+			return nil, false
+		}
+		r := c.getNested()
+		r.FindNestedMatchesResults = *results
+		return r, true
+	}
+
+	r := c.getNested()
+	matches := r.matches
+
+	if realPath == "" || realPath == "/" {
+		if rp, ok := m.staticPatterns[""]; ok {
+			matches[rp.normalizedPattern] = r.newMatchNoParams(rp)
+		}
+		if rp, ok := m.staticPatterns["/"]; ok {
+			matches[rp.normalizedPattern] = r.newMatchNoParams(rp)
+		}
+		return r.finish()
+	}
+
+	var foundFullStatic bool
+	var realSegmentsLen int
+	r.buf = r.buf[:0]
+	for pos := 0; ; {
+		seg, next, ok := nextSegment(realPath, pos)
+		if !ok {
+			break
+		}
+		realSegmentsLen++
+		isLast := isExhausted(realPath, next)
+
+		r.buf = append(r.buf, '/')
+		r.buf = append(r.buf, seg...)
+		if rp, ok := r.findStatic(r.buf); ok {
+			matches[rp.normalizedPattern] = r.newMatchNoParams(rp)
+			if isLast {
+				foundFullStatic = true
+			}
+		}
+		if isLast {
+			r.buf = append(r.buf, '/')
+			if rp, ok := r.findStatic(r.buf); ok {
+				matches[rp.normalizedPattern] = r.newMatchNoParams(rp)
+			}
+		}
+		pos = next
+	}
+
+	if !foundFullStatic {
+		// For the catch-all pattern (e.g., "/*"), handle it specially
+		if rp, ok := m.dynamicPatterns["/*"]; ok {
+			match := r.newMatchNoParams(rp)
+			match.SplatValues = r.appendSplat(realPath, 0)
+			matches["/*"] = match
+		}
+
+		// DFS for the rest of the matches
+		r.dfsNestedMatches(m.rootNode, realPath, 0, matches)
+	}
+
+	pruneNestedMatches(matches, realSegmentsLen, nil)
+	m.enforceTrailingSlashPolicy(matches, realPath, nil)
+
+	return r.finish()
+}
+
+// newMatchNoParams is like newMatch, but for matches that, like in the
+// uncompiled implementation, have nil params.
+func (r *CompiledNestedMatches) newMatchNoParams(rp *RegisteredPattern) *Match {
+	match := r.newMatch(rp)
+	match.Params = nil
+	return match
+}
+
+func (r *CompiledNestedMatches) finish() (*CompiledNestedMatches, bool) {
+	r.results = appendSortedMatches(r.results[:0], r.matches)
+	if len(r.results) == 0 {
+		r.Release()
+		return nil, false
+	}
+
+	lastMatch := r.results[len(r.results)-1]
+	r.FindNestedMatchesResults = FindNestedMatchesResults{
+		Params:      lastMatch.Params,
+		SplatValues: lastMatch.SplatValues,
+		Matches:     r.results,
+	}
+	return r, true
+}
+
+func (r *CompiledNestedMatches) dfsNestedMatches(node *segmentNode, path string, pos int, matches matchesMap) {
+	m := r.c.m
+	seg, next, ok := nextSegment(path, pos)
+
+	if len(node.pattern) > 0 {
+		if rp := m.dynamicPatterns[node.pattern]; rp != nil {
+			// Don't process the ultimate catch-all here
+			if node.pattern != "/*" {
+				match := r.newMatch(rp)
+				for k, v := range r.params {
+					match.Params[k] = v
+				}
+				if node.nodeType == nodeSplat && ok {
+					match.SplatValues = r.appendSplat(path, pos)
+				}
+				matches[node.pattern] = match
+
+				// Check for index segment if we're at the exact depth
+				if !ok {
+					r.buf = append(r.buf[:0], node.pattern...)
+					r.buf = append(r.buf, '/')
+					if rp, found := m.dynamicPatterns[string(r.buf)]; found {
+						indexMatch := r.newMatch(rp)
+						indexMatch.Params = match.Params
+						matches[rp.normalizedPattern] = indexMatch
+					}
+				}
+			}
+		}
+	}
+
+	// If we've consumed all segments, stop
+	if !ok {
+		return
+	}
+
+	// Try static children
+	if node.children != nil {
+		if child, found := node.children[seg]; found {
+			r.dfsNestedMatches(child, path, next, matches)
+		}
+	}
+
+	// Try dynamic/splat children
+	for _, child := range node.dynChildren {
+		switch child.nodeType {
+		case nodeDynamic:
+			// A failed constraint simply falls through to the next candidate
+			if !child.constraint.allows(seg) {
+				continue
+			}
+
+			// Backtracking pattern for dynamic
+			oldVal, hadVal := r.params[child.paramName]
+			r.params[child.paramName] = seg
+
+			r.dfsNestedMatches(child, path, next, matches)
+
+			if hadVal {
+				r.params[child.paramName] = oldVal
+			} else {
+				delete(r.params, child.paramName)
+			}
+
+		case nodeSplat:
+			// For splat nodes, we collect remaining segments and don't increment depth
+			r.dfsNestedMatches(child, path, pos, matches)
+		}
+	}
+}
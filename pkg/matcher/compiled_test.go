@@ -0,0 +1,242 @@
+package matcher
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestNextSegmentMatchesParseSegments(t *testing.T) {
+	paths := []string{"", "/", "/a", "a", "/a/", "/a/b", "/a//b", "/a/b//", "//", "///a", "/a/b/c/"}
+
+	for _, path := range paths {
+		var got []string
+		for pos := 0; ; {
+			seg, next, ok := nextSegment(path, pos)
+			if !ok {
+				break
+			}
+			got = append(got, seg)
+			pos = next
+		}
+		if want := ParseSegments(path); !equalSplat(got, want) {
+			t.Errorf("nextSegment walk of %q = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestCompiledFindBestMatchParity(t *testing.T) {
+	for _, opts := range differentOptsToTest {
+		for _, tt := range getTestCases() {
+			t.Run(tt.name, func(t *testing.T) {
+				m := New(opts)
+				for _, pattern := range modifyPatternsToOpts(tt.patterns, "", opts) {
+					m.RegisterPattern(pattern)
+				}
+				c := m.Compile()
+
+				want, wantOK := m.FindBestMatch(tt.path)
+				got, gotOK := c.FindBestMatch(tt.path)
+				defer got.Release()
+
+				if gotOK != wantOK {
+					t.Fatalf("compiled FindBestMatch(%q) ok = %v, want %v", tt.path, gotOK, wantOK)
+				}
+				if !wantOK {
+					return
+				}
+				if got.normalizedPattern != want.normalizedPattern {
+					t.Errorf("compiled pattern = %q, want %q", got.normalizedPattern, want.normalizedPattern)
+				}
+				if !equalParams(got.Params, want.Params) {
+					t.Errorf("compiled params = %v, want %v", got.Params, want.Params)
+				}
+				if !equalSplat(got.SplatValues, want.SplatValues) {
+					t.Errorf("compiled splat = %v, want %v", got.SplatValues, want.SplatValues)
+				}
+			})
+		}
+	}
+}
+
+func TestCompiledFindNestedMatchesParity(t *testing.T) {
+	for _, opts := range differentOptsToTest {
+		m := New(opts)
+		for _, p := range modifyPatternsToOpts(NestedPatterns, "_index", opts) {
+			m.RegisterPattern(p)
+		}
+		c := m.Compile()
+
+		for _, tc := range NestedScenarios {
+			t.Run(tc.Path, func(t *testing.T) {
+				want, wantOK := m.FindNestedMatches(tc.Path)
+				got, gotOK := c.FindNestedMatches(tc.Path)
+				defer got.Release()
+
+				if gotOK != wantOK {
+					t.Fatalf("compiled FindNestedMatches(%q) ok = %v, want %v", tc.Path, gotOK, wantOK)
+				}
+				if !wantOK {
+					return
+				}
+				if len(got.Matches) != len(want.Matches) {
+					t.Fatalf("compiled FindNestedMatches(%q) returned %d matches, want %d", tc.Path, len(got.Matches), len(want.Matches))
+				}
+				for i := range want.Matches {
+					g, w := got.Matches[i], want.Matches[i]
+					if g.normalizedPattern != w.normalizedPattern ||
+						!equalParams(g.Params, w.Params) ||
+						!equalSplat(g.SplatValues, w.SplatValues) {
+						t.Errorf("match %d = {%q %v %v}, want {%q %v %v}",
+							i, g.normalizedPattern, g.Params, g.SplatValues, w.normalizedPattern, w.Params, w.SplatValues,
+						)
+					}
+				}
+				if !equalParams(got.Params, want.Params) || !equalSplat(got.SplatValues, want.SplatValues) {
+					t.Errorf("compiled results = {%v %v}, want {%v %v}", got.Params, got.SplatValues, want.Params, want.SplatValues)
+				}
+			})
+		}
+	}
+}
+
+func TestCompiledMatcherIsFrozen(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	m.RegisterPattern("/users/:id")
+	c := m.Compile()
+
+	m.RegisterPattern("/posts/:id")
+	m.UnregisterPattern("/users/:id")
+
+	if match, ok := c.FindBestMatch("/users/1"); !ok {
+		t.Error("compiled matcher lost a pattern unregistered after compiling")
+	} else {
+		match.Release()
+	}
+	if _, ok := c.FindBestMatch("/posts/1"); ok {
+		t.Error("compiled matcher picked up a pattern registered after compiling")
+	}
+}
+
+func TestCompiledMatcherFallback(t *testing.T) {
+	m := New(&Options{Quiet: true, CaseInsensitive: true})
+	m.RegisterPattern("/Users/:id")
+	m.RegisterPattern("/assets/**/*.map")
+	c := m.Compile()
+
+	match, ok := c.FindBestMatch("/users/42")
+	if !ok || match.Params["id"] != "42" {
+		t.Errorf("compiled fallback FindBestMatch = %v, %v", match, ok)
+	}
+	match.Release()
+
+	match, ok = c.FindBestMatch("/assets/a/b.map")
+	if !ok || !equalSplat(match.SplatValues, []string{"a", "b"}) {
+		t.Errorf("compiled fallback FindBestMatch = %v, %v", match, ok)
+	}
+	match.Release()
+	match.Release() // double release is a no-op
+}
+
+func TestCompiledMatcherAllocations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping allocation test in short mode")
+	}
+
+	m := setupNonNestedMatcherForBenchmark("medium")
+	c := m.Compile()
+
+	tests := []struct {
+		path      string
+		maxAllocs float64
+	}{
+		{"/api/v1/users", 0},
+		{"/api/v1/users/123/posts/456", 1},
+		{"/files/bucket1/deep/path/file.txt", 1},
+	}
+
+	for _, tt := range tests {
+		allocs := testing.AllocsPerRun(100, func() {
+			match, ok := c.FindBestMatch(tt.path)
+			if !ok {
+				t.Fatalf("compiled FindBestMatch(%q) found no match", tt.path)
+			}
+			match.Release()
+		})
+		if allocs > tt.maxAllocs {
+			t.Errorf("compiled FindBestMatch(%q) allocs = %v, want <= %v", tt.path, allocs, tt.maxAllocs)
+		}
+	}
+}
+
+/////////////////////////////////////////////////////////////////////
+/////// BENCHMARKS
+/////////////////////////////////////////////////////////////////////
+
+func BenchmarkCompiledFindBestMatchSimple(b *testing.B) {
+	scenarios := []struct {
+		name string
+		path string
+	}{
+		{"StaticPattern", "/api/v1/users"},
+		{"DynamicPattern", "/api/v1/users/123/posts/456"},
+		{"SplatPattern", "/files/bucket1/deep/path/file.txt"},
+	}
+
+	for _, s := range scenarios {
+		b.Run(s.name, func(b *testing.B) {
+			c := setupNonNestedMatcherForBenchmark("medium").Compile()
+			b.ReportAllocs()
+			for b.Loop() {
+				match, ok := c.FindBestMatch(s.path)
+				if ok {
+					match.Release()
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCompiledFindBestMatchAtScale(b *testing.B) {
+	for _, scale := range []string{"small", "medium", "large"} {
+		b.Run("Scale_"+scale, func(b *testing.B) {
+			c := setupNonNestedMatcherForBenchmark(scale).Compile()
+			paths := generateNonNestedPathsForBenchmark(scale)
+			b.ReportAllocs()
+			i := 0
+			for b.Loop() {
+				match, ok := c.FindBestMatch(paths[i%len(paths)])
+				if ok {
+					match.Release()
+				}
+				i++
+			}
+		})
+	}
+}
+
+func BenchmarkCompiledFindNestedMatches(b *testing.B) {
+	c := setupNestedMatcherForBenchmark().Compile()
+	paths := generateNestedPathsForBenchmark()
+	b.ReportAllocs()
+	i := 0
+	for b.Loop() {
+		results, ok := c.FindNestedMatches(paths[i%len(paths)])
+		if ok {
+			results.Release()
+		}
+		runtime.KeepAlive(results)
+		i++
+	}
+}
+
+func BenchmarkUncompiledFindNestedMatches(b *testing.B) {
+	m := setupNestedMatcherForBenchmark()
+	paths := generateNestedPathsForBenchmark()
+	b.ReportAllocs()
+	i := 0
+	for b.Loop() {
+		results, _ := m.FindNestedMatches(paths[i%len(paths)])
+		runtime.KeepAlive(results)
+		i++
+	}
+}
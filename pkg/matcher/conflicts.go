@@ -0,0 +1,93 @@
+package matcher
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrPatternConflict is wrapped by the errors returned from TryRegisterPattern
+// in strict mode.
+var ErrPatternConflict = errors.New("matcher: conflicting pattern")
+
+// checkConflicts reports whether n would be a duplicate of, or ambiguous with,
+// an already registered pattern. Patterns in pending are other expanded forms
+// of the same original pattern that have not been registered yet. Expanded
+// forms of the same original pattern are never considered ambiguous with each
+// other, as their precedence is well defined (fullest form first).
+func (m *Matcher) checkConflicts(n *RegisteredPattern, pending []*RegisteredPattern) error {
+	if existing, ok := m.findStatic(n.normalizedPattern); ok {
+		return newDuplicateErr(n, existing)
+	}
+	if existing, ok := m.dynamicPatterns[n.normalizedPattern]; ok {
+		return newDuplicateErr(n, existing)
+	}
+	for _, p := range pending {
+		if m.staticKey(p.normalizedPattern) == m.staticKey(n.normalizedPattern) {
+			return newDuplicateErr(n, p)
+		}
+	}
+
+	if getIsStatic(n.normalizedSegments) {
+		return nil
+	}
+
+	// Walk the trie alongside the new pattern. At every dynamic segment, any
+	// existing sibling param with the same constraint but a different name
+	// would compete for exactly the same real segments.
+	current := m.rootNode
+	for _, seg := range n.normalizedSegments {
+		if seg.segType == segTypes.dynamic {
+			for _, sibling := range current.dynChildren {
+				if sibling.nodeType != nodeDynamic ||
+					sibling.paramName == seg.paramName ||
+					sibling.constraint.rawOrEmpty() != seg.constraint.rawOrEmpty() ||
+					sibling.prefix != seg.prefix ||
+					sibling.suffix != seg.suffix {
+					continue
+				}
+				if other := m.findPatternInSubtreeNotFrom(sibling, n.originalPattern); other != nil {
+					return fmt.Errorf(
+						"%w: pattern '%s' has param '%s' where already registered pattern '%s' has param '%s'",
+						ErrPatternConflict, n.originalPattern, seg.paramName, other.originalPattern, sibling.paramName,
+					)
+				}
+			}
+		}
+
+		next := current.findChild(seg)
+		if next == nil {
+			break
+		}
+		current = next
+	}
+
+	return nil
+}
+
+// findPatternInSubtreeNotFrom returns any pattern registered at or below node
+// that did not come from the given original pattern.
+func (m *Matcher) findPatternInSubtreeNotFrom(node *segmentNode, originalPattern string) *RegisteredPattern {
+	if node.pattern != "" {
+		if rp := m.dynamicPatterns[node.pattern]; rp != nil && rp.originalPattern != originalPattern {
+			return rp
+		}
+	}
+	for _, child := range node.children {
+		if rp := m.findPatternInSubtreeNotFrom(child, originalPattern); rp != nil {
+			return rp
+		}
+	}
+	for _, child := range node.dynChildren {
+		if rp := m.findPatternInSubtreeNotFrom(child, originalPattern); rp != nil {
+			return rp
+		}
+	}
+	return nil
+}
+
+func newDuplicateErr(n, existing *RegisteredPattern) error {
+	return fmt.Errorf(
+		"%w: pattern '%s' normalizes to '%s', which is already registered by pattern '%s'",
+		ErrPatternConflict, n.originalPattern, n.normalizedPattern, existing.originalPattern,
+	)
+}
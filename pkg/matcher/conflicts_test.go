@@ -0,0 +1,197 @@
+package matcher
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTryRegisterPatternStrict(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         *Options
+		registered   []string
+		pattern      string
+		wantConflict bool
+	}{
+		{
+			name:         "sibling params with different names and a shared suffix",
+			registered:   []string{"/:a/x"},
+			pattern:      "/:b/x",
+			wantConflict: true,
+		},
+		{
+			name:         "sibling params with different names at the same node",
+			registered:   []string{"/users/:id"},
+			pattern:      "/users/:user_id/posts",
+			wantConflict: true,
+		},
+		{
+			name:         "sibling params with the same name are fine",
+			registered:   []string{"/users/:id"},
+			pattern:      "/users/:id/posts",
+			wantConflict: false,
+		},
+		{
+			name:         "sibling params with different constraints are fine",
+			registered:   []string{"/users/:id<int>"},
+			pattern:      "/users/:username",
+			wantConflict: false,
+		},
+		{
+			name:         "sibling params with the same constraint conflict",
+			registered:   []string{"/users/:id<int>"},
+			pattern:      "/users/:num<int>",
+			wantConflict: true,
+		},
+		{
+			name:         "exact duplicate static",
+			registered:   []string{"/users"},
+			pattern:      "/users",
+			wantConflict: true,
+		},
+		{
+			name:         "exact duplicate dynamic",
+			registered:   []string{"/users/:id"},
+			pattern:      "/users/:id",
+			wantConflict: true,
+		},
+		{
+			name:         "effective duplicate via explicit index segment",
+			opts:         &Options{ExplicitIndexSegment: "_index"},
+			registered:   []string{"/users"},
+			pattern:      "/users/",
+			wantConflict: true,
+		},
+		{
+			name:         "expanded form duplicates an existing pattern",
+			registered:   []string{"/docs"},
+			pattern:      "/docs/:version?",
+			wantConflict: true,
+		},
+		{
+			name:         "expanded forms of the same pattern do not conflict with each other",
+			pattern:      "/docs/:version?/:page?",
+			wantConflict: false,
+		},
+		{
+			name:         "static and dynamic siblings are fine",
+			registered:   []string{"/users/new"},
+			pattern:      "/users/:id",
+			wantConflict: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{Quiet: true}
+			if tt.opts != nil {
+				opts = *tt.opts
+				opts.Quiet = true
+			}
+			opts.Strict = true
+
+			m := New(&opts)
+			for _, p := range tt.registered {
+				if _, err := m.TryRegisterPattern(p); err != nil {
+					t.Fatalf("TryRegisterPattern(%q) unexpected error: %v", p, err)
+				}
+			}
+			routesBefore := m.Routes()
+
+			_, err := m.TryRegisterPattern(tt.pattern)
+
+			if tt.wantConflict {
+				if !errors.Is(err, ErrPatternConflict) {
+					t.Fatalf("TryRegisterPattern(%q) error = %v, want ErrPatternConflict", tt.pattern, err)
+				}
+				if !reflect.DeepEqual(m.Routes(), routesBefore) {
+					t.Errorf("TryRegisterPattern(%q) registered something despite returning an error", tt.pattern)
+				}
+			} else if err != nil {
+				t.Fatalf("TryRegisterPattern(%q) unexpected error: %v", tt.pattern, err)
+			}
+
+			// Non-strict mode never errors
+			m = New(&Options{Quiet: true, ExplicitIndexSegment: opts.ExplicitIndexSegment})
+			for _, p := range append(tt.registered, tt.pattern) {
+				if _, err := m.TryRegisterPattern(p); err != nil {
+					t.Errorf("TryRegisterPattern(%q) in non-strict mode returned error: %v", p, err)
+				}
+			}
+		})
+	}
+}
+
+func TestRegisterPatternStrictPanics(t *testing.T) {
+	m := New(&Options{Strict: true})
+	m.RegisterPattern("/:a/x")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected RegisterPattern to panic on conflict in strict mode")
+		}
+	}()
+	m.RegisterPattern("/:b/x")
+}
+
+func TestRoutes(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	m.RegisterPattern("/users/:id<int>")
+	m.RegisterPattern("/")
+	m.RegisterPattern("/files/*")
+	m.RegisterPattern("/users")
+	m.RegisterPattern("/posts/:slug")
+
+	want := []RouteInfo{
+		{
+			Pattern:         "/",
+			OriginalPattern: "/",
+			IsStatic:        true,
+			Score:           scoreStaticMatch,
+			Segments: []SegmentInfo{
+				{Value: "", Type: "index", Score: scoreStaticMatch},
+			},
+		},
+		{
+			Pattern:         "/files/*",
+			OriginalPattern: "/files/*",
+			Score:           scoreStaticMatch,
+			Segments: []SegmentInfo{
+				{Value: "files", Type: "static", Score: scoreStaticMatch},
+				{Value: "*", Type: "splat"},
+			},
+		},
+		{
+			Pattern:         "/posts/:slug",
+			OriginalPattern: "/posts/:slug",
+			Score:           scoreStaticMatch + scoreDynamic,
+			Segments: []SegmentInfo{
+				{Value: "posts", Type: "static", Score: scoreStaticMatch},
+				{Value: ":slug", Type: "dynamic", ParamName: "slug", Score: scoreDynamic},
+			},
+		},
+		{
+			Pattern:         "/users",
+			OriginalPattern: "/users",
+			IsStatic:        true,
+			Score:           scoreStaticMatch,
+			Segments: []SegmentInfo{
+				{Value: "users", Type: "static", Score: scoreStaticMatch},
+			},
+		},
+		{
+			Pattern:         "/users/:id<int>",
+			OriginalPattern: "/users/:id<int>",
+			Score:           scoreStaticMatch + scoreConstrainedDynamic,
+			Segments: []SegmentInfo{
+				{Value: "users", Type: "static", Score: scoreStaticMatch},
+				{Value: ":id<int>", Type: "dynamic", ParamName: "id", Constraint: "int", Score: scoreConstrainedDynamic},
+			},
+		},
+	}
+
+	if got := m.Routes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Routes() =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,115 @@
+package matcher
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Dynamic segments may carry a constraint in angle brackets, e.g. ":id<int>",
+// ":uuid<uuid>", or ":slug<[a-z0-9-]+>". Anything that isn't a built-in
+// constraint name is treated as a regular expression that must match the
+// entire segment. Because patterns are split on slashes before constraints
+// are parsed, a constraint can never contain a slash.
+
+const (
+	constraintOpen  = '<'
+	constraintClose = '>'
+)
+
+type paramConstraint struct {
+	raw   string
+	check func(string) bool
+}
+
+var builtinConstraints = map[string]func(string) bool{
+	"int":   isIntSegment,
+	"uuid":  isUUIDSegment,
+	"alpha": isAlphaSegment,
+	"alnum": isAlnumSegment,
+}
+
+func newParamConstraint(raw string) *paramConstraint {
+	if raw == "" {
+		return nil
+	}
+	if check, ok := builtinConstraints[raw]; ok {
+		return &paramConstraint{raw: raw, check: check}
+	}
+	re, err := regexp.Compile("^(?:" + raw + ")$")
+	if err != nil {
+		panic(fmt.Sprintf("invalid param constraint '%s': %v", raw, err))
+	}
+	return &paramConstraint{raw: raw, check: re.MatchString}
+}
+
+func (c *paramConstraint) allows(val string) bool {
+	return c == nil || c.check(val)
+}
+
+func (c *paramConstraint) rawOrEmpty() string {
+	if c == nil {
+		return ""
+	}
+	return c.raw
+}
+
+func isIntSegment(s string) bool {
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil
+}
+
+func isUUIDSegment(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i := range len(s) {
+		switch i {
+		case 8, 13, 18, 23:
+			if s[i] != '-' {
+				return false
+			}
+		default:
+			if !isHexByte(s[i]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func isAlphaSegment(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := range len(s) {
+		if !isAlphaByte(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlnumSegment(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := range len(s) {
+		if !isAlphaByte(s[i]) && !isDigitByte(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isHexByte(b byte) bool {
+	return isDigitByte(b) || (b >= 'a' && b <= 'f') || (b >= 'A' && b <= 'F')
+}
+
+func isAlphaByte(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+func isDigitByte(b byte) bool {
+	return b >= '0' && b <= '9'
+}
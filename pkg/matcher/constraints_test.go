@@ -0,0 +1,187 @@
+package matcher
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindBestMatchWithConstraints(t *testing.T) {
+	tests := []struct {
+		name        string
+		patterns    []string
+		path        string
+		wantPattern string
+		wantParams  Params
+	}{
+		{
+			name:        "int constraint matches digits",
+			patterns:    []string{"/users/:id<int>"},
+			path:        "/users/123",
+			wantPattern: "/users/:id<int>",
+			wantParams:  Params{"id": "123"},
+		},
+		{
+			name:        "int constraint rejects non-digits",
+			patterns:    []string{"/users/:id<int>"},
+			path:        "/users/abc",
+			wantPattern: NOT_FOUND,
+		},
+		{
+			name:        "failed constraint falls through to unconstrained sibling",
+			patterns:    []string{"/users/:id<int>", "/users/:username"},
+			path:        "/users/bob",
+			wantPattern: "/users/:username",
+			wantParams:  Params{"username": "bob"},
+		},
+		{
+			name:        "constrained param beats unconstrained sibling",
+			patterns:    []string{"/users/:username", "/users/:id<int>"},
+			path:        "/users/42",
+			wantPattern: "/users/:id<int>",
+			wantParams:  Params{"id": "42"},
+		},
+		{
+			name:        "static still beats constrained param",
+			patterns:    []string{"/users/:id<int>", "/users/1"},
+			path:        "/users/1",
+			wantPattern: "/users/1",
+		},
+		{
+			name:        "failed constraint falls through to splat",
+			patterns:    []string{"/files/:id<uuid>", "/files/*"},
+			path:        "/files/not-a-uuid",
+			wantPattern: "/files/*",
+		},
+		{
+			name:        "uuid constraint",
+			patterns:    []string{"/files/:id<uuid>", "/files/*"},
+			path:        "/files/123e4567-e89b-12d3-a456-426614174000",
+			wantPattern: "/files/:id<uuid>",
+			wantParams:  Params{"id": "123e4567-e89b-12d3-a456-426614174000"},
+		},
+		{
+			name:        "regex constraint must match entire segment",
+			patterns:    []string{"/posts/:slug<[a-z0-9-]+>"},
+			path:        "/posts/Hello-World",
+			wantPattern: NOT_FOUND,
+		},
+		{
+			name:        "regex constraint",
+			patterns:    []string{"/posts/:slug<[a-z0-9-]+>"},
+			path:        "/posts/hello-world-2",
+			wantPattern: "/posts/:slug<[a-z0-9-]+>",
+			wantParams:  Params{"slug": "hello-world-2"},
+		},
+		{
+			name:        "failed constraint deeper in the tree backtracks",
+			patterns:    []string{"/api/:version<v[0-9]+>/users", "/api/:section/users"},
+			path:        "/api/beta/users",
+			wantPattern: "/api/:section/users",
+			wantParams:  Params{"section": "beta"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(&Options{Quiet: true})
+			for _, p := range tt.patterns {
+				m.RegisterPattern(p)
+			}
+
+			match, ok := m.FindBestMatch(tt.path)
+
+			if tt.wantPattern == NOT_FOUND {
+				if ok {
+					t.Errorf("FindBestMatch(%q) = %q, want no match", tt.path, match.normalizedPattern)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("FindBestMatch(%q) found no match, want %q", tt.path, tt.wantPattern)
+			}
+			if match.normalizedPattern != tt.wantPattern {
+				t.Errorf("FindBestMatch(%q) pattern = %q, want %q", tt.path, match.normalizedPattern, tt.wantPattern)
+			}
+			if !equalParams(match.Params, tt.wantParams) {
+				t.Errorf("FindBestMatch(%q) params = %v, want %v", tt.path, match.Params, tt.wantParams)
+			}
+		})
+	}
+}
+
+func TestFindNestedMatchesWithConstraints(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	m.RegisterPattern("/users")
+	m.RegisterPattern("/users/:id<int>")
+	m.RegisterPattern("/users/:id<int>/posts")
+	m.RegisterPattern("/users/*")
+
+	results, ok := m.FindNestedMatches("/users/123/posts")
+	if !ok {
+		t.Fatal("FindNestedMatches found no matches")
+	}
+	got := make([]string, 0, len(results.Matches))
+	for _, match := range results.Matches {
+		got = append(got, match.normalizedPattern)
+	}
+	want := []string{"/users", "/users/:id<int>", "/users/:id<int>/posts"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindNestedMatches patterns = %v, want %v", got, want)
+	}
+	if !equalParams(results.Params, Params{"id": "123"}) {
+		t.Errorf("FindNestedMatches params = %v, want %v", results.Params, Params{"id": "123"})
+	}
+
+	results, ok = m.FindNestedMatches("/users/abc/posts")
+	if !ok {
+		t.Fatal("FindNestedMatches found no matches")
+	}
+	got = got[:0]
+	for _, match := range results.Matches {
+		got = append(got, match.normalizedPattern)
+	}
+	want = []string{"/users", "/users/*"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindNestedMatches patterns = %v, want %v", got, want)
+	}
+}
+
+func TestNormalizePatternWithConstraints(t *testing.T) {
+	tests := []struct {
+		opts           *Options
+		pattern        string
+		wantNormalized string
+		wantParamName  string
+		wantConstraint string
+	}{
+		{nil, "/users/:id<int>", "/users/:id<int>", "id", "int"},
+		{nil, "/users/:id", "/users/:id", "id", ""},
+		{nil, "/posts/:slug<[a-z]{2,}>", "/posts/:slug<[a-z]{2,}>", "slug", "[a-z]{2,}"},
+		{&Options{DynamicParamPrefixRune: '$'}, "/users/$id<int>", "/users/:id<int>", "id", "int"},
+		{&Options{DynamicParamPrefixRune: '<'}, "/users/<id<int>", "/users/:id<int>", "id", "int"},
+	}
+
+	for _, tt := range tests {
+		m := New(tt.opts)
+		rp := m.NormalizePattern(tt.pattern)
+		if rp.normalizedPattern != tt.wantNormalized {
+			t.Errorf("NormalizePattern(%q) = %q, want %q", tt.pattern, rp.normalizedPattern, tt.wantNormalized)
+		}
+		last := rp.normalizedSegments[len(rp.normalizedSegments)-1]
+		if last.paramName != tt.wantParamName {
+			t.Errorf("NormalizePattern(%q) param name = %q, want %q", tt.pattern, last.paramName, tt.wantParamName)
+		}
+		if last.constraint.rawOrEmpty() != tt.wantConstraint {
+			t.Errorf("NormalizePattern(%q) constraint = %q, want %q", tt.pattern, last.constraint.rawOrEmpty(), tt.wantConstraint)
+		}
+	}
+}
+
+func TestInvalidConstraintPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for invalid constraint regex")
+		}
+	}()
+	New(nil).RegisterPattern("/posts/:slug<[a-z>")
+}
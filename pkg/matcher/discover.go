@@ -0,0 +1,116 @@
+package matcher
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Route files are mapped to patterns using these file name conventions, with
+// directories mapping to segments:
+//
+//   - "users.tsx"         -> "/users"
+//   - "users/$id.tsx"     -> "/users/:id"
+//   - "files/$.tsx"       -> "/files/*"
+//   - "users/_index.tsx"  -> "/users/" (or "/users/_index", with an explicit index segment)
+//   - "_index.tsx"        -> "/" (or "/_index", with an explicit index segment)
+//
+// The resulting patterns use the matcher's configured DynamicParamPrefixRune,
+// SplatSegmentRune, and ExplicitIndexSegment. Files and directories starting
+// with a dot are skipped.
+
+const (
+	discoverParamPrefix  = "$"
+	discoverIndexSegment = "_index"
+)
+
+type DiscoverOptions struct {
+	// Optional. Defaults to every file. If set, only files with one of these
+	// extensions (e.g., ".tsx") are treated as route files.
+	Extensions []string
+}
+
+// DiscoverRoutes walks fsys and returns a map of patterns to the route files
+// (slash-separated paths relative to the root of fsys) that define them. The
+// patterns are not registered. An error is returned if two files map to the
+// same pattern.
+func (m *Matcher) DiscoverRoutes(fsys fs.FS, opts *DiscoverOptions) (map[string]string, error) {
+	if opts == nil {
+		opts = new(DiscoverOptions)
+	}
+
+	routes := make(map[string]string)
+
+	err := fs.WalkDir(fsys, ".", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if filePath != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		ext := path.Ext(filePath)
+		if len(opts.Extensions) > 0 && !slices.Contains(opts.Extensions, ext) {
+			return nil
+		}
+
+		pattern := m.filePathToPattern(strings.TrimSuffix(filePath, ext))
+		if existing, ok := routes[pattern]; ok {
+			return fmt.Errorf("matcher.DiscoverRoutes: files '%s' and '%s' both map to pattern '%s'", existing, filePath, pattern)
+		}
+		routes[pattern] = filePath
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return routes, nil
+}
+
+// DiscoverRoutesInDir is like DiscoverRoutes, but walks a directory on disk.
+func (m *Matcher) DiscoverRoutesInDir(dir string, opts *DiscoverOptions) (map[string]string, error) {
+	return m.DiscoverRoutes(os.DirFS(dir), opts)
+}
+
+// filePathToPattern converts a slash-separated file path, without its
+// extension, into a pattern.
+func (m *Matcher) filePathToPattern(filePath string) string {
+	var sb strings.Builder
+
+	parts := strings.Split(filePath, "/")
+	for i, part := range parts {
+		isLast := i == len(parts)-1
+
+		switch {
+		case isLast && part == discoverIndexSegment:
+			sb.WriteString("/")
+			sb.WriteString(m.explicitIndexSegment)
+
+		case part == discoverParamPrefix:
+			sb.WriteString("/")
+			sb.WriteRune(m.splatSegmentRune)
+
+		case strings.HasPrefix(part, discoverParamPrefix):
+			sb.WriteString("/")
+			sb.WriteRune(m.dynamicParamPrefixRune)
+			sb.WriteString(part[len(discoverParamPrefix):])
+
+		default:
+			sb.WriteString("/")
+			sb.WriteString(part)
+		}
+	}
+
+	return sb.String()
+}
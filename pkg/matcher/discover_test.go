@@ -0,0 +1,122 @@
+package matcher
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDiscoverRoutes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"_index.tsx":             {},
+		"about.tsx":              {},
+		"users.tsx":              {},
+		"users/_index.tsx":       {},
+		"users/$id.tsx":          {},
+		"users/$id/edit.tsx":     {},
+		"files/$.tsx":            {},
+		"styles.css":             {},
+		".hidden/secret.tsx":     {},
+		"components/.gitkeep":    {},
+		"img/$name.png.tsx":      {},
+		"teams/$team/_index.tsx": {},
+	}
+	extOpts := &DiscoverOptions{Extensions: []string{".tsx"}}
+
+	tests := []struct {
+		name string
+		opts *Options
+		want map[string]string
+	}{
+		{
+			name: "defaults",
+			opts: &Options{},
+			want: map[string]string{
+				"/":               "_index.tsx",
+				"/about":          "about.tsx",
+				"/users":          "users.tsx",
+				"/users/":         "users/_index.tsx",
+				"/users/:id":      "users/$id.tsx",
+				"/users/:id/edit": "users/$id/edit.tsx",
+				"/files/*":        "files/$.tsx",
+				"/img/:name.png":  "img/$name.png.tsx",
+				"/teams/:team/":   "teams/$team/_index.tsx",
+			},
+		},
+		{
+			name: "custom runes and explicit index segment",
+			opts: &Options{DynamicParamPrefixRune: '<', SplatSegmentRune: '>', ExplicitIndexSegment: "_index"},
+			want: map[string]string{
+				"/_index":             "_index.tsx",
+				"/about":              "about.tsx",
+				"/users":              "users.tsx",
+				"/users/_index":       "users/_index.tsx",
+				"/users/<id":          "users/$id.tsx",
+				"/users/<id/edit":     "users/$id/edit.tsx",
+				"/files/>":            "files/$.tsx",
+				"/img/<name.png":      "img/$name.png.tsx",
+				"/teams/<team/_index": "teams/$team/_index.tsx",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(tt.opts)
+			got, err := m.DiscoverRoutes(fsys, extOpts)
+			if err != nil {
+				t.Fatalf("DiscoverRoutes() error = %v", err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("DiscoverRoutes() = %v, want %v", got, tt.want)
+			}
+
+			// Every discovered pattern must be registrable and matchable
+			for pattern := range got {
+				m.RegisterPattern(pattern)
+			}
+			if _, ok := m.FindBestMatch("/users/42/edit"); !ok {
+				t.Error("FindBestMatch(/users/42/edit) found no match")
+			}
+		})
+	}
+}
+
+func TestDiscoverRoutesWithoutExtensionFilter(t *testing.T) {
+	m := New(nil)
+	got, err := m.DiscoverRoutes(fstest.MapFS{"about.tsx": {}, "styles.css": {}}, nil)
+	if err != nil {
+		t.Fatalf("DiscoverRoutes() error = %v", err)
+	}
+	if want := map[string]string{"/about": "about.tsx", "/styles": "styles.css"}; !maps.Equal(got, want) {
+		t.Errorf("DiscoverRoutes() = %v, want %v", got, want)
+	}
+}
+
+func TestDiscoverRoutesDuplicate(t *testing.T) {
+	m := New(nil)
+	_, err := m.DiscoverRoutes(fstest.MapFS{"about.tsx": {}, "about.ts": {}}, nil)
+	if err == nil {
+		t.Error("DiscoverRoutes() should fail when two files map to the same pattern")
+	}
+}
+
+func TestDiscoverRoutesInDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "users"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "users", "$id.tsx"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := New(nil).DiscoverRoutesInDir(dir, nil)
+	if err != nil {
+		t.Fatalf("DiscoverRoutesInDir() error = %v", err)
+	}
+	if want := map[string]string{"/users/:id": "users/$id.tsx"}; !maps.Equal(got, want) {
+		t.Errorf("DiscoverRoutesInDir() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,280 @@
+package matcher
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// ExplainRule names the rule that kept or removed a candidate pattern.
+type ExplainRule string
+
+const (
+	// Kept because the whole path matched a static pattern, which always wins.
+	RuleStaticMatch ExplainRule = "static-match"
+	// Kept because it had the highest score of any candidate FindBestMatch visited.
+	RuleHighestScore ExplainRule = "highest-score"
+	// Removed because another candidate had a higher score.
+	RuleLowerScore ExplainRule = "lower-score"
+	// Removed because an earlier candidate had the same score.
+	RuleEarlierTie ExplainRule = "earlier-tie"
+	// A splat child is only chosen by FindBestMatch if nothing else has
+	// matched yet. Kept or removed depending on whether anything else did.
+	RuleSplatFallback ExplainRule = "splat-fallback"
+	// Removed because the real segments couldn't be bound to the pattern's
+	// wildcards, globstars, or affixes.
+	RuleBindFailed ExplainRule = "bind-failed"
+
+	// Kept as part of the nested match chain.
+	RuleNestedMatch ExplainRule = "nested-match"
+	// Removed because the catch-all ("/*") is dropped whenever anything else matches.
+	RuleCatchAllShadowed ExplainRule = "catch-all-shadowed"
+	// Removed because splats and indexes only survive at the longest matched length.
+	RuleShorterSplatOrIndex ExplainRule = "shorter-splat-or-index"
+	// Removed because an index at the longest matched length loses to its siblings.
+	RuleIndexAtLongest ExplainRule = "index-at-longest"
+	// Removed because a dynamic sibling consumed exactly the real path.
+	RuleSplatLosesToDynamic ExplainRule = "splat-loses-to-dynamic"
+	// Removed because the real path is longer than the dynamic sibling.
+	RuleDynamicLosesToSplat ExplainRule = "dynamic-loses-to-splat"
+	// Removed because a longer expansion of the same optional-segment pattern matched.
+	RuleShorterExpansion ExplainRule = "shorter-expansion"
+	// Removed because, under TrailingSlashStrict, nothing in the chain matched
+	// the real path's trailing slash.
+	RuleTrailingSlashStrict ExplainRule = "trailing-slash-strict"
+)
+
+// ExplainedCandidate is a pattern that was considered while matching a real
+// path, and what became of it.
+type ExplainedCandidate struct {
+	*RegisteredPattern
+
+	// For FindBestMatch candidates found by the trie search, the score the
+	// search accumulated. Otherwise, the sum of the pattern's segment scores.
+	Score int
+
+	Kept bool
+	Rule ExplainRule
+
+	// The values the candidate would have extracted from the real path.
+	Params      Params
+	SplatValues []string
+}
+
+// Explanation describes how FindBestMatch and FindNestedMatches arrived at
+// their results for a real path. Candidates are listed in the order they
+// were visited (best) or in nested order (nested).
+type Explanation struct {
+	RealPath string
+
+	Best           *Match // what FindBestMatch returns (nil if no match)
+	BestCandidates []*ExplainedCandidate
+
+	Nested           *FindNestedMatchesResults // what FindNestedMatches returns (nil if no match)
+	NestedCandidates []*ExplainedCandidate
+}
+
+// Explain runs both FindBestMatch and FindNestedMatches for the real path,
+// recording every candidate pattern visited along the way and the rule that
+// kept or removed it. It is meant for debugging and tests. It is much slower
+// than the Find methods, so don't use it to serve requests.
+func (m *Matcher) Explain(realPath string) *Explanation {
+	e := &Explanation{RealPath: realPath}
+	m.explainBest(e)
+	m.explainNested(e)
+	return e
+}
+
+// bestTrace records the candidates visited by findBestMatch.
+type bestTrace struct {
+	static     *RegisteredPattern // set if the static fast path matched
+	candidates []bestCandidate
+	chosen     *RegisteredPattern // chosen by the search, before binding
+
+	segments     []string
+	realSegments []string
+}
+
+type bestCandidate struct {
+	rp            *RegisteredPattern
+	score         uint16
+	splatFallback bool
+}
+
+func (t *bestTrace) recordStatic(rp *RegisteredPattern) {
+	if t != nil {
+		t.static = rp
+	}
+}
+
+// record is a no-op on a nil trace, so that the search can call it
+// unconditionally. A pattern reached more than once (e.g., through a
+// globstar) keeps its best visit.
+func (t *bestTrace) record(rp *RegisteredPattern, score uint16, splatFallback bool) {
+	if t == nil {
+		return
+	}
+	for i, c := range t.candidates {
+		if c.rp == rp {
+			if !splatFallback && (c.splatFallback || score > c.score) {
+				t.candidates[i] = bestCandidate{rp: rp, score: score}
+			}
+			return
+		}
+	}
+	t.candidates = append(t.candidates, bestCandidate{rp: rp, score: score, splatFallback: splatFallback})
+}
+
+func (m *Matcher) explainBest(e *Explanation) {
+	trace := new(bestTrace)
+	if best, ok := m.findBestMatch(e.RealPath, trace); ok {
+		e.Best = best
+	}
+
+	if trace.static != nil {
+		e.BestCandidates = []*ExplainedCandidate{{
+			RegisteredPattern: trace.static,
+			Score:             patternScore(trace.static),
+			Kept:              true,
+			Rule:              RuleStaticMatch,
+		}}
+		return
+	}
+
+	var chosenScore uint16
+	for _, c := range trace.candidates {
+		if c.rp == trace.chosen {
+			chosenScore = c.score
+		}
+	}
+
+	for _, c := range trace.candidates {
+		candidate := &ExplainedCandidate{RegisteredPattern: c.rp, Score: int(c.score)}
+
+		match := &Match{RegisteredPattern: c.rp}
+		bound := m.bindMatch(match, trace.segments, trace.realSegments)
+		if bound {
+			candidate.Params, candidate.SplatValues = match.Params, match.SplatValues
+		}
+
+		switch {
+		case c.rp == trace.chosen && !bound:
+			candidate.Rule = RuleBindFailed
+		case c.rp == trace.chosen:
+			candidate.Kept = true
+			candidate.Rule = RuleHighestScore
+			if c.splatFallback {
+				candidate.Rule = RuleSplatFallback
+			}
+		case c.splatFallback:
+			candidate.Rule = RuleSplatFallback
+		case c.score < chosenScore:
+			candidate.Rule = RuleLowerScore
+		default:
+			candidate.Rule = RuleEarlierTie
+		}
+
+		e.BestCandidates = append(e.BestCandidates, candidate)
+	}
+}
+
+func (m *Matcher) explainNested(e *Explanation) {
+	matches := make(matchesMap)
+	realSegmentsLen, needsPruning := m.collectNestedMatches(e.RealPath, matches)
+
+	candidates := make(map[pattern]*ExplainedCandidate, len(matches))
+	for pattern, match := range matches {
+		rule := RuleNestedMatch
+		if _, isStatic := m.staticPatterns[pattern]; isStatic {
+			rule = RuleStaticMatch
+		}
+		candidates[pattern] = &ExplainedCandidate{
+			RegisteredPattern: match.RegisteredPattern,
+			Score:             patternScore(match.RegisteredPattern),
+			Kept:              true,
+			Rule:              rule,
+			Params:            match.Params,
+			SplatValues:       match.SplatValues,
+		}
+	}
+
+	recorder := removalRecorder(func(match *Match, rule ExplainRule) {
+		candidate := candidates[match.normalizedPattern]
+		candidate.Kept = false
+		candidate.Rule = rule
+	})
+
+	if needsPruning {
+		pruneNestedMatches(matches, realSegmentsLen, recorder)
+		m.enforceTrailingSlashPolicy(matches, e.RealPath, recorder)
+	}
+	dedupeExpandedMatches(matches, recorder)
+
+	if results, ok := flattenAndSortMatches(matches); ok {
+		e.Nested = results
+	}
+
+	for _, candidate := range candidates {
+		e.NestedCandidates = append(e.NestedCandidates, candidate)
+	}
+	slices.SortFunc(e.NestedCandidates, func(a, b *ExplainedCandidate) int {
+		if a.lastSegIsIndex != b.lastSegIsIndex {
+			if a.lastSegIsIndex {
+				return 1
+			}
+			return -1
+		}
+		if n := len(a.normalizedSegments) - len(b.normalizedSegments); n != 0 {
+			return n
+		}
+		return strings.Compare(a.normalizedPattern, b.normalizedPattern)
+	})
+}
+
+// patternScore returns the sum of the scores of the pattern's segments.
+func patternScore(rp *RegisteredPattern) int {
+	var score int
+	for _, seg := range rp.normalizedSegments {
+		score += getSegmentScore(seg)
+	}
+	return score
+}
+
+// String renders the explanation as a human-readable report, with kept
+// candidates marked "+" and removed candidates marked "-".
+func (e *Explanation) String() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "FindBestMatch(%q):\n", e.RealPath)
+	writeCandidates(&sb, e.BestCandidates)
+
+	fmt.Fprintf(&sb, "FindNestedMatches(%q):\n", e.RealPath)
+	writeCandidates(&sb, e.NestedCandidates)
+
+	return sb.String()
+}
+
+func writeCandidates(sb *strings.Builder, candidates []*ExplainedCandidate) {
+	if len(candidates) == 0 {
+		sb.WriteString("  (no candidates)\n")
+		return
+	}
+
+	tw := tabwriter.NewWriter(sb, 0, 0, 2, ' ', 0)
+	for _, c := range candidates {
+		mark := "-"
+		if c.Kept {
+			mark = "+"
+		}
+		fmt.Fprintf(tw, "  %s %s\tscore=%d\t%s", mark, c.normalizedPattern, c.Score, c.Rule)
+		if len(c.Params) > 0 {
+			fmt.Fprintf(tw, "\tparams=%v", c.Params)
+		}
+		if len(c.SplatValues) > 0 {
+			fmt.Fprintf(tw, "\tsplat=%v", c.SplatValues)
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+}
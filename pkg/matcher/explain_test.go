@@ -0,0 +1,174 @@
+package matcher
+
+import (
+	"strings"
+	"testing"
+)
+
+func findCandidate(candidates []*ExplainedCandidate, pattern string) *ExplainedCandidate {
+	for _, c := range candidates {
+		if c.normalizedPattern == pattern {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestExplain(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	for _, p := range []string{"/*", "/users", "/users/", "/users/:id", "/users/*", "/users/:id/edit", "/users/new"} {
+		m.RegisterPattern(p)
+	}
+
+	type want struct {
+		pattern string
+		kept    bool
+		rule    ExplainRule
+	}
+
+	tests := []struct {
+		path   string
+		best   []want
+		nested []want
+	}{
+		{
+			path: "/users/42",
+			best: []want{
+				{"/users/:id", true, RuleHighestScore},
+				{"/users/*", false, RuleSplatFallback},
+				{"/*", false, RuleSplatFallback},
+			},
+			nested: []want{
+				{"/*", false, RuleCatchAllShadowed},
+				{"/users", true, RuleStaticMatch},
+				{"/users/*", false, RuleSplatLosesToDynamic},
+				{"/users/:id", true, RuleNestedMatch},
+			},
+		},
+		{
+			path: "/users/42/a/b",
+			best: []want{
+				{"/users/*", true, RuleSplatFallback},
+				{"/*", false, RuleSplatFallback},
+			},
+			nested: []want{
+				{"/users/*", true, RuleNestedMatch},
+				{"/users/:id", false, RuleDynamicLosesToSplat},
+			},
+		},
+		{
+			path: "/users/42/edit",
+			nested: []want{
+				{"/users/:id", true, RuleNestedMatch},
+				{"/users/:id/edit", true, RuleNestedMatch},
+				{"/users/*", false, RuleShorterSplatOrIndex},
+			},
+		},
+		{
+			path: "/users/new",
+			best: []want{{"/users/new", true, RuleStaticMatch}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			e := m.Explain(tt.path)
+
+			if len(tt.best) > 0 && len(e.BestCandidates) != len(tt.best) {
+				t.Errorf("got %d best candidates, want %d:\n%s", len(e.BestCandidates), len(tt.best), e)
+			}
+
+			check := func(kind string, candidates []*ExplainedCandidate, wants []want) {
+				for _, w := range wants {
+					c := findCandidate(candidates, w.pattern)
+					if c == nil {
+						t.Errorf("%s: %q is not a candidate:\n%s", kind, w.pattern, e)
+						continue
+					}
+					if c.Kept != w.kept || c.Rule != w.rule {
+						t.Errorf("%s: %q = {kept: %v, rule: %s}, want {kept: %v, rule: %s}", kind, w.pattern, c.Kept, c.Rule, w.kept, w.rule)
+					}
+				}
+			}
+			check("best", e.BestCandidates, tt.best)
+			check("nested", e.NestedCandidates, tt.nested)
+		})
+	}
+}
+
+func TestExplainCandidateDetails(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	m.RegisterPattern("/users/:id")
+	m.RegisterPattern("/:section/:id")
+	m.RegisterPattern("/files/**/:name.txt")
+
+	e := m.Explain("/users/42")
+	kept := findCandidate(e.BestCandidates, "/users/:id")
+	lost := findCandidate(e.BestCandidates, "/:section/:id")
+	if kept == nil || lost == nil {
+		t.Fatalf("missing candidates:\n%s", e)
+	}
+	if kept.Score != 5 || lost.Score != 2 {
+		t.Errorf("scores = %d, %d, want 5, 2", kept.Score, lost.Score)
+	}
+	if lost.Rule != RuleLowerScore || lost.Params["section"] != "users" || lost.Params["id"] != "42" {
+		t.Errorf("losing candidate = {rule: %s, params: %v}, want lower score with both params", lost.Rule, lost.Params)
+	}
+
+	e = m.Explain("/files/a/b/c.txt")
+	c := findCandidate(e.BestCandidates, "/files/**/:name.txt")
+	if c == nil || c.Params["name"] != "c" || !equalSplat(c.SplatValues, []string{"a", "b"}) {
+		t.Fatalf("globstar candidate not bound correctly:\n%s", e)
+	}
+
+	if s := m.Explain("/nope").String(); !strings.Contains(s, "(no candidates)") {
+		t.Errorf("String() = %q, want it to note the lack of candidates", s)
+	}
+}
+
+// Explain must agree with the Find methods it explains.
+func TestExplainParity(t *testing.T) {
+	for _, opts := range differentOptsToTest {
+		for _, tt := range getTestCases() {
+			m := New(opts)
+			for _, pattern := range modifyPatternsToOpts(tt.patterns, "", opts) {
+				m.RegisterPattern(pattern)
+			}
+
+			want, wantOK := m.FindBestMatch(tt.path)
+			got := m.Explain(tt.path).Best
+			if (got != nil) != wantOK || wantOK && got.normalizedPattern != want.normalizedPattern {
+				t.Errorf("%s: Explain(%q).Best = %v, want %v", tt.name, tt.path, got, want)
+			}
+		}
+
+		m := New(opts)
+		for _, p := range modifyPatternsToOpts(NestedPatterns, "_index", opts) {
+			m.RegisterPattern(p)
+		}
+		for _, tc := range NestedScenarios {
+			want, wantOK := m.FindNestedMatches(tc.Path)
+			e := m.Explain(tc.Path)
+			if (e.Nested != nil) != wantOK {
+				t.Fatalf("Explain(%q).Nested = %v, want ok = %v", tc.Path, e.Nested, wantOK)
+			}
+			if !wantOK {
+				continue
+			}
+
+			var kept []string
+			for _, c := range e.NestedCandidates {
+				if c.Kept {
+					kept = append(kept, c.normalizedPattern)
+				}
+			}
+			var wantPatterns []string
+			for _, match := range want.Matches {
+				wantPatterns = append(wantPatterns, match.normalizedPattern)
+			}
+			if !equalSplat(kept, wantPatterns) {
+				t.Errorf("Explain(%q) kept %v, want %v", tc.Path, kept, wantPatterns)
+			}
+		}
+	}
+}
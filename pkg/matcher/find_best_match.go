@@ -1,16 +1,30 @@
 package matcher
 
 func (m *Matcher) FindBestMatch(realPath string) (*Match, bool) {
-	if rr, ok := m.staticPatterns[realPath]; ok {
-		return &Match{RegisteredPattern: rr}, true
+	return m.findBestMatch(realPath, nil)
+}
+
+// findBestMatch implements FindBestMatch. If trace is non-nil, every
+// candidate the search considers is recorded to it (see Explain).
+func (m *Matcher) findBestMatch(realPath string, trace *bestTrace) (*Match, bool) {
+	lookupPath, canLookupStatic := m.normalizeRealPath(realPath)
+
+	if canLookupStatic {
+		if rr, ok := m.findStatic(lookupPath); ok {
+			trace.recordStatic(rr)
+			return &Match{RegisteredPattern: rr}, true
+		}
 	}
 
-	segments := ParseSegments(realPath)
-	hasTrailingSlash := len(realPath) > 0 && realPath[len(realPath)-1] == '/'
+	realSegments := ParseSegments(realPath)
+	segments := m.normalizeRealSegments(realSegments)
+	hasTrailingSlash := len(realPath) > 0 && realPath[len(realPath)-1] == '/' &&
+		m.trailingSlash != TrailingSlashStrict
 
-	if hasTrailingSlash {
-		pathWithoutTrailingSlash := realPath[:len(realPath)-1]
-		if rr, ok := m.staticPatterns[pathWithoutTrailingSlash]; ok {
+	if hasTrailingSlash && canLookupStatic {
+		pathWithoutTrailingSlash := lookupPath[:len(lookupPath)-1]
+		if rr, ok := m.findStatic(pathWithoutTrailingSlash); ok {
+			trace.recordStatic(rr)
 			return &Match{RegisteredPattern: rr}, true
 		}
 	}
@@ -19,27 +33,51 @@ func (m *Matcher) FindBestMatch(realPath string) (*Match, bool) {
 	var bestScore uint16
 	foundMatch := false
 
-	m.dfsBest(m.rootNode, segments, 0, 0, best, &bestScore, &foundMatch, hasTrailingSlash)
+	m.dfsBest(m.rootNode, segments, 0, 0, best, &bestScore, &foundMatch, hasTrailingSlash, trace)
 
 	if !foundMatch {
 		return nil, false
 	}
 
-	if best.numberOfDynamicParamSegs > 0 {
-		params := make(Params, best.numberOfDynamicParamSegs)
-		for i, seg := range best.normalizedSegments {
+	if trace != nil {
+		trace.chosen = best.RegisteredPattern
+		trace.segments, trace.realSegments = segments, realSegments
+	}
+
+	if !m.bindMatch(best, segments, realSegments) {
+		return nil, false
+	}
+
+	return best, true
+}
+
+// bindMatch sets the params and splat values of a match found by dfsBest,
+// and reports whether the real segments could be bound to its pattern.
+func (m *Matcher) bindMatch(match *Match, segments, realSegments []string) bool {
+	if match.hasComplexSegments {
+		params, splatValues, ok := m.bindSegments(match.RegisteredPattern, segments, realSegments)
+		if !ok {
+			return false
+		}
+		match.Params, match.SplatValues = params, splatValues
+		return true
+	}
+
+	if match.numberOfDynamicParamSegs > 0 {
+		params := make(Params, match.numberOfDynamicParamSegs)
+		for i, seg := range match.normalizedSegments {
 			if seg.segType == segTypes.dynamic {
-				params[seg.normalizedVal[1:]] = segments[i]
+				params[seg.paramName] = realSegments[i]
 			}
 		}
-		best.Params = params
+		match.Params = params
 	}
 
-	if best.normalizedPattern == "/*" || best.lastSegIsNonRootSplat {
-		best.SplatValues = segments[len(best.normalizedSegments)-1:]
+	if match.normalizedPattern == "/*" || match.lastSegIsNonRootSplat {
+		match.SplatValues = realSegments[len(match.normalizedSegments)-1:]
 	}
 
-	return best, true
+	return true
 }
 
 func (m *Matcher) dfsBest(
@@ -51,12 +89,14 @@ func (m *Matcher) dfsBest(
 	bestScore *uint16,
 	foundMatch *bool,
 	checkTrailingSlash bool,
+	trace *bestTrace,
 ) {
 	atNormalEnd := checkTrailingSlash && depth == len(segments)-1
 
 	if len(node.pattern) > 0 {
 		if rp, ok := m.dynamicPatterns[node.pattern]; ok {
 			if depth == len(segments) || node.nodeType == nodeSplat || atNormalEnd {
+				trace.record(rp, score, false)
 				if !*foundMatch || score > *bestScore {
 					best.RegisteredPattern = rp
 					best.score = score
@@ -67,13 +107,23 @@ func (m *Matcher) dfsBest(
 		}
 	}
 
+	// Globstars may consume zero segments, so they are tried even when all
+	// segments have already been consumed
+	for _, child := range node.dynChildren {
+		if child.nodeType == nodeGlobstar {
+			for next := depth; next <= len(segments); next++ {
+				m.dfsBest(child, segments, next, score+uint16(child.segScore), best, bestScore, foundMatch, checkTrailingSlash, trace)
+			}
+		}
+	}
+
 	if depth >= len(segments) {
 		return
 	}
 
 	if node.children != nil {
-		if child, ok := node.children[segments[depth]]; ok {
-			m.dfsBest(child, segments, depth+1, score+scoreStaticMatch, best, bestScore, foundMatch, checkTrailingSlash)
+		if child, ok := node.children[m.staticKey(segments[depth])]; ok {
+			m.dfsBest(child, segments, depth+1, score+scoreStaticMatch, best, bestScore, foundMatch, checkTrailingSlash, trace)
 
 			if *foundMatch && depth+1 == len(segments) && child.pattern != "" {
 				return
@@ -83,15 +133,18 @@ func (m *Matcher) dfsBest(
 
 	for _, child := range node.dynChildren {
 		switch child.nodeType {
-		case nodeDynamic:
-			// Don't match empty segments to dynamic parameters
-			if segments[depth] != "" {
-				m.dfsBest(child, segments, depth+1, score+scoreDynamic, best, bestScore, foundMatch, checkTrailingSlash)
+		case nodeDynamic, nodeWildcard:
+			// Empty segments, failed constraints, and mismatched affixes simply
+			// fall through to the next candidate
+			if _, ok := m.matchInner(segments[depth], child.prefix, child.suffix, child.constraint); !ok {
+				continue
 			}
+			m.dfsBest(child, segments, depth+1, score+uint16(child.segScore), best, bestScore, foundMatch, checkTrailingSlash, trace)
 
 		case nodeSplat:
 			if len(child.pattern) > 0 {
 				if rp := m.dynamicPatterns[child.pattern]; rp != nil {
+					trace.record(rp, score, true)
 					if !*foundMatch {
 						best.RegisteredPattern = rp
 						*foundMatch = true
@@ -13,26 +13,45 @@ type FindNestedMatchesResults struct {
 }
 
 func (m *Matcher) FindNestedMatches(realPath string) (*FindNestedMatchesResults, bool) {
-	realSegments := ParseSegments(realPath)
 	matches := make(matchesMap)
+	realSegmentsLen, needsPruning := m.collectNestedMatches(realPath, matches)
+	if needsPruning {
+		pruneNestedMatches(matches, realSegmentsLen, nil)
+		m.enforceTrailingSlashPolicy(matches, realPath, nil)
+	}
+	return flattenAndSortMatches(matches)
+}
+
+// collectNestedMatches adds every pattern that matches a prefix of the real
+// path (the candidates for the nested match chain) to matches. It returns the
+// number of segments in the real path, and whether the candidates still need
+// pruning. The caller owns matches, so that it can stay on the stack.
+func (m *Matcher) collectNestedMatches(realPath string, matches matchesMap) (int, bool) {
+	realSegments := ParseSegments(realPath)
+	segments := m.normalizeRealSegments(realSegments)
 
 	if realPath == "" || realPath == "/" {
-		if rr, ok := m.staticPatterns[""]; ok {
+		if rr, ok := m.findStatic(""); ok {
 			matches[rr.normalizedPattern] = &Match{RegisteredPattern: rr}
 		}
-		if rr, ok := m.staticPatterns["/"]; ok {
+		if rr, ok := m.findStatic("/"); ok {
 			matches[rr.normalizedPattern] = &Match{RegisteredPattern: rr}
 		}
-		return flattenAndSortMatches(matches)
+		return 0, false
 	}
 
 	var pb strings.Builder
 	pb.Grow(len(realPath) + 1)
 	var foundFullStatic bool
-	for i := range realSegments {
+	for i := range segments {
+		// A decoded "%2F" must not line up with a slash in a static pattern,
+		// so neither this prefix nor any longer one can be static
+		if m.decodePercentEncoding && strings.IndexByte(segments[i], '/') != -1 {
+			break
+		}
 		pb.WriteString("/")
-		pb.WriteString(realSegments[i])
-		if rr, ok := m.staticPatterns[pb.String()]; ok {
+		pb.WriteString(segments[i])
+		if rr, ok := m.findStatic(pb.String()); ok {
 			matches[rr.normalizedPattern] = &Match{RegisteredPattern: rr}
 			if i == len(realSegments)-1 {
 				foundFullStatic = true
@@ -40,7 +59,7 @@ func (m *Matcher) FindNestedMatches(realPath string) (*FindNestedMatchesResults,
 		}
 		if i == len(realSegments)-1 {
 			pb.WriteString("/")
-			if rr, ok := m.staticPatterns[pb.String()]; ok {
+			if rr, ok := m.findStatic(pb.String()); ok {
 				matches[rr.normalizedPattern] = &Match{RegisteredPattern: rr}
 			}
 		}
@@ -57,18 +76,39 @@ func (m *Matcher) FindNestedMatches(realPath string) (*FindNestedMatchesResults,
 
 		// DFS for the rest of the matches
 		params := make(Params)
-		m.dfsNestedMatches(m.rootNode, realSegments, 0, params, matches)
+		m.dfsNestedMatches(m.rootNode, segments, realSegments, 0, params, nil, matches)
+	}
+
+	return len(realSegments), true
+}
+
+// removalRecorder, if non-nil, is told about each match removed while
+// pruning, along with the rule that removed it (see Explain).
+type removalRecorder func(match *Match, rule ExplainRule)
+
+func (r removalRecorder) remove(matches matchesMap, pattern string, rule ExplainRule) {
+	match, ok := matches[pattern]
+	if !ok {
+		return
 	}
+	if r != nil {
+		r(match, rule)
+	}
+	delete(matches, pattern)
+}
 
+// pruneNestedMatches removes the matches that shouldn't be part of a nested
+// match chain for a real path with the given number of segments.
+func pruneNestedMatches(matches matchesMap, realSegmentsLen int, recorder removalRecorder) {
 	// if there are multiple matches and a catch-all, remove the catch-all
 	if _, ok := matches["/*"]; ok {
 		if len(matches) > 1 {
-			delete(matches, "/*")
+			recorder.remove(matches, "/*", RuleCatchAllShadowed)
 		}
 	}
 
 	if len(matches) < 2 {
-		return flattenAndSortMatches(matches)
+		return
 	}
 
 	var longestSegmentLen int
@@ -88,13 +128,13 @@ func (m *Matcher) FindNestedMatches(realPath string) (*FindNestedMatchesResults,
 	for pattern, match := range matches {
 		if len(match.normalizedSegments) < longestSegmentLen {
 			if match.lastSegIsNonRootSplat || match.lastSegIsIndex {
-				delete(matches, pattern)
+				recorder.remove(matches, pattern, RuleShorterSplatOrIndex)
 			}
 		}
 	}
 
 	if len(matches) < 2 {
-		return flattenAndSortMatches(matches)
+		return
 	}
 
 	// if the longest segment length items are (1) dynamic, (2) splat, or (3) index, remove them as follows:
@@ -102,28 +142,56 @@ func (m *Matcher) FindNestedMatches(realPath string) (*FindNestedMatchesResults,
 	// - if the realSegmentLen is greater than the longest segment length, prioritize splat, and always remove dynamic and index
 	if len(longestSegmentMatches) > 1 {
 		if match, indexExists := longestSegmentMatches[segTypes.index]; indexExists {
-			delete(matches, match.normalizedPattern)
+			recorder.remove(matches, match.normalizedPattern, RuleIndexAtLongest)
 		}
 
 		_, dynamicExists := longestSegmentMatches[segTypes.dynamic]
 		_, splatExists := longestSegmentMatches[segTypes.splat]
 
-		if len(realSegments) == longestSegmentLen && dynamicExists && splatExists {
-			delete(matches, longestSegmentMatches[segTypes.splat].normalizedPattern)
+		if realSegmentsLen == longestSegmentLen && dynamicExists && splatExists {
+			recorder.remove(matches, longestSegmentMatches[segTypes.splat].normalizedPattern, RuleSplatLosesToDynamic)
 		}
-		if len(realSegments) > longestSegmentLen && splatExists && dynamicExists {
-			delete(matches, longestSegmentMatches[segTypes.dynamic].normalizedPattern)
+		if realSegmentsLen > longestSegmentLen && splatExists && dynamicExists {
+			recorder.remove(matches, longestSegmentMatches[segTypes.dynamic].normalizedPattern, RuleDynamicLosesToSplat)
 		}
 	}
+}
 
-	return flattenAndSortMatches(matches)
+// enforceTrailingSlashPolicy removes every match if the policy is
+// TrailingSlashStrict and the real path has a trailing slash that the nested
+// match chain doesn't account for. Like FindBestMatch, only an index pattern,
+// or a pattern ending in a splat or globstar, may match a trailing slash.
+func (m *Matcher) enforceTrailingSlashPolicy(matches matchesMap, realPath string, recorder removalRecorder) {
+	if m.trailingSlash != TrailingSlashStrict || len(realPath) < 2 || realPath[len(realPath)-1] != '/' {
+		return
+	}
+
+	var longestSegmentLen int
+	for _, match := range matches {
+		if match.lastSegIsIndex {
+			return
+		}
+		longestSegmentLen = max(longestSegmentLen, len(match.normalizedSegments))
+	}
+	for _, match := range matches {
+		if len(match.normalizedSegments) == longestSegmentLen &&
+			(match.lastSegType == segTypes.splat || match.lastSegType == segTypes.globstar) {
+			return
+		}
+	}
+
+	for pattern := range matches {
+		recorder.remove(matches, pattern, RuleTrailingSlashStrict)
+	}
 }
 
 func (m *Matcher) dfsNestedMatches(
 	node *segmentNode,
 	segments []string,
+	realSegments []string,
 	depth int,
 	params Params,
+	captured []string, // values matched so far by wildcards and globstars
 	matches matchesMap,
 ) {
 	if len(node.pattern) > 0 {
@@ -135,10 +203,12 @@ func (m *Matcher) dfsNestedMatches(
 				maps.Copy(paramsCopy, params)
 
 				var splatValues []string
+				if len(captured) > 0 {
+					splatValues = slices.Clone(captured)
+				}
 				if node.nodeType == nodeSplat && depth < len(segments) {
 					// For splat nodes, collect all remaining segments
-					splatValues = make([]string, len(segments)-depth)
-					copy(splatValues, segments[depth:])
+					splatValues = append(splatValues, realSegments[depth:]...)
 				}
 
 				match := &Match{
@@ -166,6 +236,17 @@ func (m *Matcher) dfsNestedMatches(
 		}
 	}
 
+	// Globstars may consume zero segments, so they are tried even when all
+	// segments have already been consumed. Greedier consumptions are tried
+	// last, so they win when they reach the same pattern.
+	for _, child := range node.dynChildren {
+		if child.nodeType == nodeGlobstar {
+			for next := depth; next <= len(segments); next++ {
+				m.dfsNestedMatches(child, segments, realSegments, next, params, append(captured[:len(captured):len(captured)], realSegments[depth:next]...), matches)
+			}
+		}
+	}
+
 	// If we've consumed all segments, stop
 	if depth >= len(segments) {
 		return
@@ -175,8 +256,8 @@ func (m *Matcher) dfsNestedMatches(
 
 	// Try static children
 	if node.children != nil {
-		if child, ok := node.children[seg]; ok {
-			m.dfsNestedMatches(child, segments, depth+1, params, matches)
+		if child, ok := node.children[m.staticKey(seg)]; ok {
+			m.dfsNestedMatches(child, segments, realSegments, depth+1, params, captured, matches)
 		}
 	}
 
@@ -184,11 +265,21 @@ func (m *Matcher) dfsNestedMatches(
 	for _, child := range node.dynChildren {
 		switch child.nodeType {
 		case nodeDynamic:
+			// A failed constraint or mismatched affix simply falls through to
+			// the next candidate
+			inner, ok := seg, child.constraint.allows(seg)
+			if child.prefix != "" || child.suffix != "" {
+				inner, ok = m.matchInner(seg, child.prefix, child.suffix, child.constraint)
+			}
+			if !ok {
+				continue
+			}
+
 			// Backtracking pattern for dynamic
 			oldVal, hadVal := params[child.paramName]
-			params[child.paramName] = seg
+			params[child.paramName] = m.realInner(realSegments[depth], inner, child.prefix, child.suffix)
 
-			m.dfsNestedMatches(child, segments, depth+1, params, matches)
+			m.dfsNestedMatches(child, segments, realSegments, depth+1, params, captured, matches)
 
 			if hadVal {
 				params[child.paramName] = oldVal
@@ -196,15 +287,42 @@ func (m *Matcher) dfsNestedMatches(
 				delete(params, child.paramName)
 			}
 
+		case nodeWildcard:
+			inner, ok := m.matchInner(seg, child.prefix, child.suffix, nil)
+			if !ok {
+				continue
+			}
+			value := m.realInner(realSegments[depth], inner, child.prefix, child.suffix)
+			m.dfsNestedMatches(child, segments, realSegments, depth+1, params, append(captured[:len(captured):len(captured)], value), matches)
+
 		case nodeSplat:
 			// For splat nodes, we collect remaining segments and don't increment depth
-			m.dfsNestedMatches(child, segments, depth, params, matches)
+			m.dfsNestedMatches(child, segments, realSegments, depth, params, captured, matches)
 		}
 	}
 }
 
 func flattenAndSortMatches(matches matchesMap) (*FindNestedMatchesResults, bool) {
-	var results []*Match
+	results := appendSortedMatches(nil, matches)
+
+	if len(results) == 0 {
+		return nil, false
+	}
+
+	lastMatch := results[len(results)-1]
+
+	return &FindNestedMatchesResults{
+		Params:      lastMatch.Params,
+		SplatValues: lastMatch.SplatValues,
+		Matches:     results,
+	}, true
+}
+
+// appendSortedMatches appends the matches to results in nested order
+// (outermost first, index last).
+func appendSortedMatches(results []*Match, matches matchesMap) []*Match {
+	dedupeExpandedMatches(matches, nil)
+
 	for _, match := range matches {
 		results = append(results, match)
 	}
@@ -222,15 +340,34 @@ func flattenAndSortMatches(matches matchesMap) (*FindNestedMatchesResults, bool)
 		return len(i.normalizedSegments) - len(j.normalizedSegments)
 	})
 
-	if len(results) == 0 {
-		return nil, false
-	}
+	return results
+}
 
-	lastMatch := results[len(results)-1]
+// dedupeExpandedMatches ensures that a pattern with optional segments only
+// appears once in a nested match chain, keeping whichever of its expanded
+// forms consumed the most segments.
+func dedupeExpandedMatches(matches matchesMap, recorder removalRecorder) {
+	var longestByOriginal map[string]*Match
 
-	return &FindNestedMatchesResults{
-		Params:      lastMatch.Params,
-		SplatValues: lastMatch.SplatValues,
-		Matches:     results,
-	}, true
+	for _, match := range matches {
+		if match.optionalSegments == nil {
+			continue
+		}
+		if longestByOriginal == nil {
+			longestByOriginal = make(map[string]*Match)
+		}
+		existing, ok := longestByOriginal[match.originalPattern]
+		if !ok || len(match.normalizedSegments) > len(existing.normalizedSegments) {
+			longestByOriginal[match.originalPattern] = match
+		}
+	}
+
+	for pattern, match := range matches {
+		if match.optionalSegments == nil {
+			continue
+		}
+		if longestByOriginal[match.originalPattern] != match {
+			recorder.remove(matches, pattern, RuleShorterExpansion)
+		}
+	}
 }
@@ -0,0 +1,254 @@
+package matcher
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Patterns may be qualified with a host by starting them with "//", as in a
+// scheme-relative URL, e.g. "//:tenant.example.com/dashboard/:id". Everything
+// between the leading "//" and the next slash is the host part, and the rest
+// is an ordinary path pattern. A host part with no path, e.g.
+// "//admin.example.com", is the same as "//admin.example.com/". Patterns
+// without the leading "//" are never host-qualified, whatever their first
+// segment looks like (e.g. "v1.2/docs" or "robots.txt").
+//
+// The host part is split on dots into labels. Each label may be static
+// ("example"), a dynamic param (":tenant", optionally with a constraint or a
+// static prefix/suffix), or a wildcard ("*") matching exactly one label. Host
+// parts never include a port, and hosts are always compared
+// case-insensitively.
+//
+// Host-qualified patterns are only considered by FindBestMatchForHost and
+// FindNestedMatchesForHost. Those try every host pattern that matches the host
+// (most specific first), and fall back to the host-agnostic patterns if none
+// of them matches the path. Host params are merged into Match.Params; a path
+// param with the same name takes precedence.
+
+type hostRoute struct {
+	host    string // normalized host part, e.g. ":tenant.example.com"
+	labels  []*segment
+	score   int
+	matcher *Matcher // path patterns registered for this host
+}
+
+// SplitHostPattern splits a host-qualified pattern into its host and path
+// parts, e.g. "//:tenant.example.com/dashboard" into ":tenant.example.com" and
+// "/dashboard". Returns false if the pattern is not host-qualified.
+func SplitHostPattern(pattern string) (host, path string, ok bool) {
+	rest, ok := strings.CutPrefix(pattern, "//")
+	if !ok {
+		return "", "", false
+	}
+	idx := strings.IndexByte(rest, '/')
+	if idx == -1 {
+		return rest, "/", true
+	}
+	return rest[:idx], rest[idx:], true
+}
+
+func (m *Matcher) parseHost(host string) []*segment {
+	rawLabels := strings.Split(host, ".")
+	labels := make([]*segment, 0, len(rawLabels))
+	for _, raw := range rawLabels {
+		// Host names can't contain a literal ':' or '*', so there is nothing
+		// to escape
+		if strings.IndexByte(raw, '\\') != -1 {
+			panic(fmt.Sprintf("matcher: escapes are not supported in host pattern '%s'", host))
+		}
+		label := m.parseSegment(raw, false)
+		switch label.segType {
+		case segTypes.index:
+			panic(fmt.Sprintf("matcher: empty label in host pattern '%s'", host))
+		case segTypes.globstar:
+			panic(fmt.Sprintf("matcher: globstars are not supported in host pattern '%s'", host))
+		}
+		// Fold the static parts of the label, leaving param names and
+		// constraints alone
+		if label.segType == segTypes.static {
+			label.normalizedVal = strings.ToLower(label.normalizedVal)
+		} else if label.hasAffix() {
+			inner := label.normalizedVal[len(label.prefix) : len(label.normalizedVal)-len(label.suffix)]
+			label.prefix, label.suffix = strings.ToLower(label.prefix), strings.ToLower(label.suffix)
+			label.normalizedVal = label.prefix + inner + label.suffix
+		}
+		labels = append(labels, label)
+	}
+	return labels
+}
+
+func joinLabels(labels []*segment) string {
+	var sb strings.Builder
+	for i, label := range labels {
+		if i > 0 {
+			sb.WriteByte('.')
+		}
+		sb.WriteString(label.normalizedVal)
+	}
+	return sb.String()
+}
+
+// hostRouteFor returns the host route for the given host part, creating it if
+// necessary.
+func (m *Matcher) hostRouteFor(host string) *hostRoute {
+	labels := m.parseHost(host)
+	normalizedHost := joinLabels(labels)
+
+	if hr, ok := m.hostRoutesByHost[normalizedHost]; ok {
+		return hr
+	}
+
+	hr := &hostRoute{host: normalizedHost, labels: labels, matcher: New(&m.opts)}
+	for _, label := range labels {
+		hr.score += getSegmentScore(label)
+	}
+
+	if m.hostRoutesByHost == nil {
+		m.hostRoutesByHost = make(map[string]*hostRoute)
+	}
+	m.hostRoutesByHost[normalizedHost] = hr
+
+	// Most specific hosts first, then hosts with more labels. Otherwise, hosts
+	// keep their registration order.
+	m.hostRoutes = append(m.hostRoutes, hr)
+	slices.SortStableFunc(m.hostRoutes, func(a, b *hostRoute) int {
+		if a.score != b.score {
+			return b.score - a.score
+		}
+		return len(b.labels) - len(a.labels)
+	})
+
+	return hr
+}
+
+func (m *Matcher) registerHostPattern(originalPattern, host, path string) (*RegisteredPattern, error) {
+	hr := m.hostRouteFor(host)
+	rp, err := hr.matcher.tryRegister(originalPattern, path)
+	if err != nil {
+		return nil, err
+	}
+	for _, form := range hr.matcher.originalPatterns[originalPattern] {
+		form.host = hr.host
+	}
+	return rp, nil
+}
+
+// matchHost matches a real host against the host route's labels, returning
+// the host params.
+func (m *Matcher) matchHost(hr *hostRoute, realLabels, lowerLabels []string) (Params, bool) {
+	if len(realLabels) != len(hr.labels) {
+		return nil, false
+	}
+
+	var params Params
+	for i, label := range hr.labels {
+		lower := lowerLabels[i]
+		switch label.segType {
+		case segTypes.static:
+			if lower != label.normalizedVal {
+				return nil, false
+			}
+		case segTypes.dynamic, segTypes.wildcard:
+			inner, ok := m.matchInner(lower, label.prefix, label.suffix, label.constraint)
+			if !ok {
+				return nil, false
+			}
+			if label.segType == segTypes.wildcard {
+				continue
+			}
+			if params == nil {
+				params = make(Params)
+			}
+			// Report the value as it appears in the real host
+			real := realLabels[i]
+			if len(real) == len(lower) {
+				inner = real[len(label.prefix) : len(real)-len(label.suffix)]
+			}
+			params[label.paramName] = inner
+		default:
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// splitRealHost strips any port and trailing dot from a real host (e.g., the
+// Host header of a request) and splits it into labels.
+func splitRealHost(host string) (realLabels, lowerLabels []string) {
+	if idx := strings.LastIndexByte(host, ':'); idx != -1 && idx > strings.LastIndexByte(host, ']') {
+		host = host[:idx]
+	}
+	host = strings.TrimSuffix(host, ".")
+	if host == "" {
+		return nil, nil
+	}
+	realLabels = strings.Split(host, ".")
+	lowerLabels = strings.Split(strings.ToLower(host), ".")
+	if len(lowerLabels) != len(realLabels) {
+		lowerLabels = realLabels
+	}
+	return realLabels, lowerLabels
+}
+
+// FindBestMatchForHost is like FindBestMatch, but also considers
+// host-qualified patterns. The host may include a port, which is ignored.
+func (m *Matcher) FindBestMatchForHost(host, realPath string) (*Match, bool) {
+	if len(m.hostRoutes) > 0 {
+		realLabels, lowerLabels := splitRealHost(host)
+		for _, hr := range m.hostRoutes {
+			hostParams, ok := m.matchHost(hr, realLabels, lowerLabels)
+			if !ok {
+				continue
+			}
+			if match, ok := hr.matcher.FindBestMatch(realPath); ok {
+				match.Params = mergeHostParams(hostParams, match.Params)
+				return match, true
+			}
+		}
+	}
+	return m.FindBestMatch(realPath)
+}
+
+// FindNestedMatchesForHost is like FindNestedMatches, but also considers
+// host-qualified patterns. The first matching host with any matches for the
+// path wins; host-specific and host-agnostic patterns are never mixed in a
+// single result.
+func (m *Matcher) FindNestedMatchesForHost(host, realPath string) (*FindNestedMatchesResults, bool) {
+	if len(m.hostRoutes) > 0 {
+		realLabels, lowerLabels := splitRealHost(host)
+		for _, hr := range m.hostRoutes {
+			hostParams, ok := m.matchHost(hr, realLabels, lowerLabels)
+			if !ok {
+				continue
+			}
+			if results, ok := hr.matcher.FindNestedMatches(realPath); ok {
+				if len(hostParams) > 0 {
+					results.Params = mergeHostParams(hostParams, results.Params)
+					for _, match := range results.Matches {
+						match.Params = mergeHostParams(hostParams, match.Params)
+					}
+				}
+				return results, true
+			}
+		}
+	}
+	return m.FindNestedMatches(realPath)
+}
+
+func mergeHostParams(hostParams, pathParams Params) Params {
+	if len(hostParams) == 0 {
+		return pathParams
+	}
+	merged := make(Params, len(hostParams)+len(pathParams))
+	maps.Copy(merged, hostParams)
+	maps.Copy(merged, pathParams)
+	return merged
+}
+
+// Host returns the normalized host part of a host-qualified pattern, or an
+// empty string if the pattern is host-agnostic.
+func (rp *RegisteredPattern) Host() string {
+	return rp.host
+}
@@ -0,0 +1,254 @@
+package matcher
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindBestMatchForHost(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	m.RegisterPattern("//:tenant.example.com/dashboard/:id")
+	m.RegisterPattern("//admin.example.com/dashboard/:id")
+	m.RegisterPattern("//*.example.com/status")
+	m.RegisterPattern("//:tenant<[a-z]+>.example.com/about")
+	m.RegisterPattern("/dashboard/:id")
+	m.RegisterPattern("/about")
+
+	tests := []struct {
+		name         string
+		host         string
+		path         string
+		wantOriginal string
+		wantParams   Params
+	}{
+		{
+			name:         "host param merged into params",
+			host:         "acme.example.com",
+			path:         "/dashboard/42",
+			wantOriginal: "//:tenant.example.com/dashboard/:id",
+			wantParams:   Params{"tenant": "acme", "id": "42"},
+		},
+		{
+			name:         "port is ignored",
+			host:         "acme.example.com:8080",
+			path:         "/dashboard/42",
+			wantOriginal: "//:tenant.example.com/dashboard/:id",
+			wantParams:   Params{"tenant": "acme", "id": "42"},
+		},
+		{
+			name:         "host is case-insensitive, param keeps original case",
+			host:         "Acme.EXAMPLE.com",
+			path:         "/dashboard/42",
+			wantOriginal: "//:tenant.example.com/dashboard/:id",
+			wantParams:   Params{"tenant": "Acme", "id": "42"},
+		},
+		{
+			name:         "static host beats dynamic host",
+			host:         "admin.example.com",
+			path:         "/dashboard/42",
+			wantOriginal: "//admin.example.com/dashboard/:id",
+			wantParams:   Params{"id": "42"},
+		},
+		{
+			name:         "wildcard label",
+			host:         "anything.example.com",
+			path:         "/status",
+			wantOriginal: "//*.example.com/status",
+		},
+		{
+			name:         "falls back to host-agnostic pattern when no host pattern matches the path",
+			host:         "acme.example.com",
+			path:         "/about",
+			wantOriginal: "//:tenant<[a-z]+>.example.com/about",
+			wantParams:   Params{"tenant": "acme"},
+		},
+		{
+			name:         "host constraint failure falls back to host-agnostic pattern",
+			host:         "acme1.example.com",
+			path:         "/about",
+			wantOriginal: "/about",
+		},
+		{
+			name:         "other domain falls back to host-agnostic pattern",
+			host:         "acme.other.com",
+			path:         "/dashboard/42",
+			wantOriginal: "/dashboard/:id",
+			wantParams:   Params{"id": "42"},
+		},
+		{
+			name:         "label count must match",
+			host:         "a.b.example.com",
+			path:         "/dashboard/42",
+			wantOriginal: "/dashboard/:id",
+			wantParams:   Params{"id": "42"},
+		},
+		{
+			name:         "no host",
+			host:         "",
+			path:         "/dashboard/42",
+			wantOriginal: "/dashboard/:id",
+			wantParams:   Params{"id": "42"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, ok := m.FindBestMatchForHost(tt.host, tt.path)
+			if !ok {
+				t.Fatalf("FindBestMatchForHost(%q, %q) found no match", tt.host, tt.path)
+			}
+			if match.OriginalPattern() != tt.wantOriginal {
+				t.Errorf("original pattern = %q, want %q", match.OriginalPattern(), tt.wantOriginal)
+			}
+			if !equalParams(match.Params, tt.wantParams) {
+				t.Errorf("params = %v, want %v", match.Params, tt.wantParams)
+			}
+		})
+	}
+
+	// Host-qualified patterns are invisible to plain FindBestMatch
+	if match, ok := m.FindBestMatch("/status"); ok {
+		t.Errorf("FindBestMatch(/status) = %q, want no match", match.OriginalPattern())
+	}
+}
+
+func TestFindNestedMatchesForHost(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	m.RegisterPattern("//:tenant.example.com/dashboard")
+	m.RegisterPattern("//:tenant.example.com/dashboard/:id")
+	m.RegisterPattern("/dashboard")
+
+	results, ok := m.FindNestedMatchesForHost("acme.example.com", "/dashboard/42")
+	if !ok {
+		t.Fatal("FindNestedMatchesForHost found no matches")
+	}
+	var got []string
+	for _, match := range results.Matches {
+		got = append(got, match.OriginalPattern())
+		if match.Params["tenant"] != "acme" {
+			t.Errorf("match %q params = %v, want tenant param", match.OriginalPattern(), match.Params)
+		}
+	}
+	if want := []string{"//:tenant.example.com/dashboard", "//:tenant.example.com/dashboard/:id"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("patterns = %v, want %v", got, want)
+	}
+	if want := (Params{"tenant": "acme", "id": "42"}); !equalParams(results.Params, want) {
+		t.Errorf("params = %v, want %v", results.Params, want)
+	}
+
+	results, ok = m.FindNestedMatchesForHost("example.org", "/dashboard")
+	if !ok || len(results.Matches) != 1 || results.Matches[0].OriginalPattern() != "/dashboard" {
+		t.Errorf("FindNestedMatchesForHost should fall back to host-agnostic patterns, got %v", results)
+	}
+}
+
+func TestHostPatternsRoutesBuildPathAndUnregister(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	m.RegisterPattern("//:tenant.Example.com/dashboard/:id")
+	m.RegisterPattern("/about")
+
+	routes := m.Routes()
+	if len(routes) != 2 {
+		t.Fatalf("Routes() returned %d routes, want 2", len(routes))
+	}
+	if routes[0].Pattern != "//:tenant.example.com/dashboard/:id" || routes[0].Host != ":tenant.example.com" {
+		t.Errorf("Routes()[0] = %+v, want host-qualified pattern", routes[0])
+	}
+
+	got, err := m.BuildPath("//:tenant.Example.com/dashboard/:id", Params{"tenant": "acme", "id": "42"}, nil)
+	if err != nil {
+		t.Fatalf("BuildPath() error = %v", err)
+	}
+	if got != "/dashboard/42" {
+		t.Errorf("BuildPath() = %q, want %q", got, "/dashboard/42")
+	}
+	if _, err := m.BuildPath("//:tenant.example.com/dashboard/:id", Params{"id": "42", "extra": "x"}, nil); err == nil {
+		t.Error("BuildPath() with an unexpected param should fail")
+	}
+
+	clone := m.Clone()
+
+	if !m.UnregisterPattern("//:tenant.Example.com/dashboard/:id") {
+		t.Fatal("UnregisterPattern() = false, want true")
+	}
+	if _, ok := m.FindBestMatchForHost("acme.example.com", "/dashboard/42"); ok {
+		t.Error("FindBestMatchForHost still matches after unregistering")
+	}
+	if _, ok := clone.FindBestMatchForHost("acme.example.com", "/dashboard/42"); !ok {
+		t.Error("unregistering from the original affected the clone")
+	}
+}
+
+func TestSlashlessPathPatternsAreNotHostQualified(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	m.RegisterPattern("users/:id")
+	m.RegisterPattern("about")
+	m.RegisterPattern("robots.txt")
+	m.RegisterPattern("files.json")
+	m.RegisterPattern("v1.2/docs")
+
+	for path, want := range map[string]string{
+		"/users/5":    "users/:id",
+		"/about":      "about",
+		"/robots.txt": "robots.txt",
+		"/files.json": "files.json",
+		"/v1.2/docs":  "v1.2/docs",
+	} {
+		match, ok := m.FindBestMatch(path)
+		if !ok {
+			t.Errorf("FindBestMatch(%q) found no match", path)
+			continue
+		}
+		if match.OriginalPattern() != want {
+			t.Errorf("FindBestMatch(%q) = %q, want %q", path, match.OriginalPattern(), want)
+		}
+		if match.Host() != "" {
+			t.Errorf("FindBestMatch(%q) host = %q, want none", path, match.Host())
+		}
+	}
+}
+
+func TestHostOnlyPattern(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	m.RegisterPattern("//admin.example.com")
+	m.RegisterPattern("/")
+
+	match, ok := m.FindBestMatchForHost("admin.example.com", "/")
+	if !ok || match.OriginalPattern() != "//admin.example.com" {
+		t.Errorf("FindBestMatchForHost(admin.example.com, /) = %v, %v, want the host-only pattern", match, ok)
+	}
+	match, ok = m.FindBestMatchForHost("www.example.com", "/")
+	if !ok || match.OriginalPattern() != "/" {
+		t.Errorf("FindBestMatchForHost(www.example.com, /) = %v, %v, want the host-agnostic pattern", match, ok)
+	}
+
+	if got, err := m.BuildPath("//admin.example.com", nil, nil); err != nil || got != "/" {
+		t.Errorf("BuildPath() = %q, %v, want /", got, err)
+	}
+	if !m.UnregisterPattern("//admin.example.com") {
+		t.Error("UnregisterPattern() = false, want true")
+	}
+}
+
+func TestSplitHostPattern(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		wantHost string
+		wantPath string
+		wantOK   bool
+	}{
+		{"//:tenant.example.com/dashboard", ":tenant.example.com", "/dashboard", true},
+		{"//example.com", "example.com", "/", true},
+		{"//example.com/", "example.com", "/", true},
+		{"/example.com/dashboard", "", "", false},
+		{"example.com/dashboard", "", "", false},
+		{"v1.2/docs", "", "", false},
+	}
+
+	for _, tt := range tests {
+		host, path, ok := SplitHostPattern(tt.pattern)
+		if host != tt.wantHost || path != tt.wantPath || ok != tt.wantOK {
+			t.Errorf("SplitHostPattern(%q) = %q, %q, %v, want %q, %q, %v", tt.pattern, host, path, ok, tt.wantHost, tt.wantPath, tt.wantOK)
+		}
+	}
+}
@@ -20,6 +20,18 @@ type Matcher struct {
 	dynamicPatterns patternsMap
 	rootNode        *segmentNode
 
+	// static patterns keyed by staticKey (only populated if foldsStatic)
+	foldedStaticPatterns patternsMap
+
+	// original pattern -> every registered form of it (more than one if expanded)
+	originalPatterns map[pattern][]*RegisteredPattern
+
+	// host-qualified patterns, grouped by host part (see host.go)
+	hostRoutes       []*hostRoute // most specific first
+	hostRoutesByHost map[string]*hostRoute
+
+	opts Options // resolved options, used for per-host matchers
+
 	explicitIndexSegment   string
 	dynamicParamPrefixRune rune
 	splatSegmentRune       rune
@@ -27,7 +39,15 @@ type Matcher struct {
 	slashIndexSegment         string
 	usingExplicitIndexSegment bool
 
-	quiet bool
+	quiet         bool
+	strict        bool
+	trailingSlash TrailingSlashPolicy
+
+	caseInsensitive       bool
+	decodePercentEncoding bool
+	normalizeUnicode      bool
+	normalizesRealPaths   bool
+	foldsStatic           bool
 }
 
 type Match struct {
@@ -47,6 +67,30 @@ type Options struct {
 	ExplicitIndexSegment string
 
 	Quiet bool // Optional. Defaults to false. Set to true if you want to quash warnings.
+
+	// Optional. Defaults to false. Set to true to reject duplicate and ambiguous
+	// registrations (e.g., "/:a/x" alongside "/:b/x") instead of warning about them.
+	// See TryRegisterPattern.
+	Strict bool
+
+	// Optional. Defaults to false. Set to true to match static segments
+	// regardless of case.
+	CaseInsensitive bool
+
+	// Optional. Defaults to false. Set to true to percent-decode each segment
+	// of a real path before matching. Encoded slashes ("%2F") never split a
+	// segment in two.
+	DecodePercentEncoding bool
+
+	// Optional. Defaults to false. Set to true to apply Unicode NFC
+	// normalization to real paths and static pattern segments before matching.
+	NormalizeUnicode bool
+
+	// Regardless of the three options above, Params and SplatValues always
+	// contain the segments exactly as they appear in the real path.
+
+	// Optional. Defaults to TrailingSlashLenient. See TrailingSlashPolicy.
+	TrailingSlash TrailingSlashPolicy
 }
 
 func New(opts *Options) *Matcher {
@@ -55,13 +99,26 @@ func New(opts *Options) *Matcher {
 	instance.staticPatterns = make(patternsMap)
 	instance.dynamicPatterns = make(patternsMap)
 	instance.rootNode = new(segmentNode)
+	instance.originalPatterns = make(map[pattern][]*RegisteredPattern)
 
 	mungedOpts := mungeOptsToDefaults(opts)
+	instance.opts = mungedOpts
 
 	instance.explicitIndexSegment = mungedOpts.ExplicitIndexSegment
 	instance.dynamicParamPrefixRune = mungedOpts.DynamicParamPrefixRune
 	instance.splatSegmentRune = mungedOpts.SplatSegmentRune
 	instance.quiet = mungedOpts.Quiet
+	instance.strict = mungedOpts.Strict
+	instance.trailingSlash = mungedOpts.TrailingSlash
+	instance.caseInsensitive = mungedOpts.CaseInsensitive
+	instance.decodePercentEncoding = mungedOpts.DecodePercentEncoding
+	instance.normalizeUnicode = mungedOpts.NormalizeUnicode
+
+	instance.normalizesRealPaths = instance.decodePercentEncoding || instance.normalizeUnicode
+	instance.foldsStatic = instance.caseInsensitive || instance.normalizeUnicode
+	if instance.foldsStatic {
+		instance.foldedStaticPatterns = make(patternsMap)
+	}
 
 	instance.slashIndexSegment = "/" + instance.explicitIndexSegment
 	instance.usingExplicitIndexSegment = instance.explicitIndexSegment != ""
@@ -84,6 +141,11 @@ func mungeOptsToDefaults(opts *Options) Options {
 	copy.SplatSegmentRune = opt.Resolve(copy, copy.SplatSegmentRune, '*')
 	copy.ExplicitIndexSegment = opt.Resolve(copy, copy.ExplicitIndexSegment, "")
 	copy.Quiet = opt.Resolve(copy, copy.Quiet, false)
+	copy.Strict = opt.Resolve(copy, copy.Strict, false)
+	copy.CaseInsensitive = opt.Resolve(copy, copy.CaseInsensitive, false)
+	copy.DecodePercentEncoding = opt.Resolve(copy, copy.DecodePercentEncoding, false)
+	copy.NormalizeUnicode = opt.Resolve(copy, copy.NormalizeUnicode, false)
+	copy.TrailingSlash = opt.Resolve(copy, copy.TrailingSlash, TrailingSlashLenient)
 
 	return copy
 }
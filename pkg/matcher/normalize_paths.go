@@ -0,0 +1,89 @@
+package matcher
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// The CaseInsensitive, DecodePercentEncoding, and NormalizeUnicode options
+// only affect how real paths are compared against registered patterns. The
+// Params and SplatValues of a match always contain the segments exactly as
+// they appear in the real path.
+
+// normalizeRealSegments returns the segments of a real path as they should be
+// used for matching. Percent-decoding happens per segment, so an encoded slash
+// ("%2F") never splits a segment in two.
+func (m *Matcher) normalizeRealSegments(realSegments []string) []string {
+	if !m.normalizesRealPaths {
+		return realSegments
+	}
+	lookupSegments := make([]string, len(realSegments))
+	for i, seg := range realSegments {
+		lookupSegments[i] = m.normalizeRealSegment(seg)
+	}
+	return lookupSegments
+}
+
+func (m *Matcher) normalizeRealSegment(seg string) string {
+	if m.decodePercentEncoding && strings.IndexByte(seg, '%') != -1 {
+		if decoded, err := url.PathUnescape(seg); err == nil {
+			seg = decoded
+		}
+	}
+	if m.normalizeUnicode {
+		seg = norm.NFC.String(seg)
+	}
+	return seg
+}
+
+// normalizeRealPath is like normalizeRealSegments, but for a whole path. The
+// bool is false if a decoded segment contains a slash, in which case the path
+// can't be compared against whole static patterns.
+func (m *Matcher) normalizeRealPath(realPath string) (string, bool) {
+	if !m.normalizesRealPaths || realPath == "" {
+		return realPath, true
+	}
+	segments := m.normalizeRealSegments(ParseSegments(realPath))
+	if !canJoinSegments(segments) {
+		return "", false
+	}
+	return "/" + strings.Join(segments, "/"), true
+}
+
+// canJoinSegments reports whether none of the segments contains a slash
+// (which only happens once "%2F" has been decoded), so that joining them
+// with slashes doesn't create new segment boundaries.
+func canJoinSegments(segments []string) bool {
+	for _, seg := range segments {
+		if strings.IndexByte(seg, '/') != -1 {
+			return false
+		}
+	}
+	return true
+}
+
+// staticKey returns the key under which a static pattern or static segment is
+// stored and looked up.
+func (m *Matcher) staticKey(s string) string {
+	if !m.foldsStatic {
+		return s
+	}
+	if m.normalizeUnicode {
+		s = norm.NFC.String(s)
+	}
+	if m.caseInsensitive {
+		s = strings.ToLower(s)
+	}
+	return s
+}
+
+func (m *Matcher) findStatic(path string) (*RegisteredPattern, bool) {
+	if !m.foldsStatic {
+		rp, ok := m.staticPatterns[path]
+		return rp, ok
+	}
+	rp, ok := m.foldedStaticPatterns[m.staticKey(path)]
+	return rp, ok
+}
@@ -0,0 +1,226 @@
+package matcher
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindBestMatchWithNormalizationModes(t *testing.T) {
+	const nfcCafe = "caf\u00e9"  // precomposed é
+	const nfdCafe = "cafe\u0301" // e followed by a combining acute accent
+	const nfdCafeEncoded = "cafe%CC%81"
+
+	tests := []struct {
+		name        string
+		opts        Options
+		patterns    []string
+		path        string
+		wantPattern string
+		wantParams  Params
+		wantSplat   []string
+	}{
+		{
+			name:        "case-sensitive by default",
+			patterns:    []string{"/Users/new"},
+			path:        "/users/new",
+			wantPattern: NOT_FOUND,
+		},
+		{
+			name:        "case-insensitive static pattern",
+			opts:        Options{CaseInsensitive: true},
+			patterns:    []string{"/Users/new"},
+			path:        "/users/NEW",
+			wantPattern: "/Users/new",
+		},
+		{
+			name:        "case-insensitive static pattern with trailing slash",
+			opts:        Options{CaseInsensitive: true},
+			patterns:    []string{"/Users"},
+			path:        "/USERS/",
+			wantPattern: "/Users",
+		},
+		{
+			name:        "case-insensitive static segments in dynamic pattern keep original param values",
+			opts:        Options{CaseInsensitive: true},
+			patterns:    []string{"/Users/:id/Posts"},
+			path:        "/users/AbC/posts",
+			wantPattern: "/Users/:id/Posts",
+			wantParams:  Params{"id": "AbC"},
+		},
+		{
+			name:        "case-insensitive does not loosen constraints",
+			opts:        Options{CaseInsensitive: true},
+			patterns:    []string{"/tags/:tag<[a-z]+>"},
+			path:        "/TAGS/ABC",
+			wantPattern: NOT_FOUND,
+		},
+		{
+			name:        "percent-encoded static segment does not match by default",
+			patterns:    []string{"/hello world"},
+			path:        "/hello%20world",
+			wantPattern: NOT_FOUND,
+		},
+		{
+			name:        "percent-decoded static segment",
+			opts:        Options{DecodePercentEncoding: true},
+			patterns:    []string{"/hello world"},
+			path:        "/hello%20world",
+			wantPattern: "/hello world",
+		},
+		{
+			name:        "percent-decoding keeps original param values",
+			opts:        Options{DecodePercentEncoding: true},
+			patterns:    []string{"/search/:query<[a-z ]+>"},
+			path:        "/search/foo%20bar",
+			wantPattern: "/search/:query<[a-z ]+>",
+			wantParams:  Params{"query": "foo%20bar"},
+		},
+		{
+			name:        "percent-decoding does not split encoded slashes",
+			opts:        Options{DecodePercentEncoding: true},
+			patterns:    []string{"/files/:name", "/files/:dir/:name"},
+			path:        "/files/a%2Fb",
+			wantPattern: "/files/:name",
+			wantParams:  Params{"name": "a%2Fb"},
+		},
+		{
+			name:        "decoded encoded slash does not match a static pattern",
+			opts:        Options{DecodePercentEncoding: true},
+			patterns:    []string{"/a/b"},
+			path:        "/a%2Fb",
+			wantPattern: NOT_FOUND,
+		},
+		{
+			name:        "percent-decoding keeps original splat values",
+			opts:        Options{DecodePercentEncoding: true},
+			patterns:    []string{"/files/*"},
+			path:        "/files/a%20b/c",
+			wantPattern: "/files/*",
+			wantSplat:   []string{"a%20b", "c"},
+		},
+		{
+			name:        "unicode forms differ by default",
+			patterns:    []string{"/" + nfcCafe},
+			path:        "/" + nfdCafe,
+			wantPattern: NOT_FOUND,
+		},
+		{
+			name:        "unicode NFC normalization",
+			opts:        Options{NormalizeUnicode: true},
+			patterns:    []string{"/" + nfcCafe},
+			path:        "/" + nfdCafe,
+			wantPattern: "/" + nfcCafe,
+		},
+		{
+			name:        "unicode NFC normalization of pattern",
+			opts:        Options{NormalizeUnicode: true},
+			patterns:    []string{"/" + nfdCafe + "/:id"},
+			path:        "/" + nfcCafe + "/1",
+			wantPattern: "/" + nfdCafe + "/:id",
+			wantParams:  Params{"id": "1"},
+		},
+		{
+			name:        "all modes combined keep original param values",
+			opts:        Options{CaseInsensitive: true, DecodePercentEncoding: true, NormalizeUnicode: true},
+			patterns:    []string{"/" + nfcCafe + "/:item"},
+			path:        "/CAFE%CC%81/" + nfdCafeEncoded,
+			wantPattern: "/" + nfcCafe + "/:item",
+			wantParams:  Params{"item": nfdCafeEncoded},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.Quiet = true
+			m := New(&opts)
+			for _, p := range tt.patterns {
+				m.RegisterPattern(p)
+			}
+
+			match, ok := m.FindBestMatch(tt.path)
+
+			if tt.wantPattern == NOT_FOUND {
+				if ok {
+					t.Errorf("FindBestMatch(%q) = %q, want no match", tt.path, match.normalizedPattern)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("FindBestMatch(%q) found no match, want %q", tt.path, tt.wantPattern)
+			}
+			if match.normalizedPattern != tt.wantPattern {
+				t.Errorf("FindBestMatch(%q) pattern = %q, want %q", tt.path, match.normalizedPattern, tt.wantPattern)
+			}
+			if !equalParams(match.Params, tt.wantParams) {
+				t.Errorf("FindBestMatch(%q) params = %v, want %v", tt.path, match.Params, tt.wantParams)
+			}
+			if !equalSplat(match.SplatValues, tt.wantSplat) {
+				t.Errorf("FindBestMatch(%q) splat = %v, want %v", tt.path, match.SplatValues, tt.wantSplat)
+			}
+		})
+	}
+}
+
+func TestFindNestedMatchesWithNormalizationModes(t *testing.T) {
+	m := New(&Options{Quiet: true, CaseInsensitive: true, DecodePercentEncoding: true})
+	m.RegisterPattern("/Dashboard")
+	m.RegisterPattern("/Dashboard/:team")
+	m.RegisterPattern("/Dashboard/:team/*")
+
+	results, ok := m.FindNestedMatches("/dashboard/My%20Team/a%20b/c")
+	if !ok {
+		t.Fatal("FindNestedMatches found no matches")
+	}
+
+	var got []string
+	for _, match := range results.Matches {
+		got = append(got, match.normalizedPattern)
+	}
+	if want := []string{"/Dashboard", "/Dashboard/:team", "/Dashboard/:team/*"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FindNestedMatches patterns = %v, want %v", got, want)
+	}
+	if want := (Params{"team": "My%20Team"}); !equalParams(results.Params, want) {
+		t.Errorf("FindNestedMatches params = %v, want %v", results.Params, want)
+	}
+	if want := []string{"a%20b", "c"}; !equalSplat(results.SplatValues, want) {
+		t.Errorf("FindNestedMatches splat = %v, want %v", results.SplatValues, want)
+	}
+}
+
+func TestFindNestedMatchesDoesNotSplitEncodedSlashes(t *testing.T) {
+	m := New(&Options{Quiet: true, DecodePercentEncoding: true})
+	m.RegisterPattern("/a")
+	m.RegisterPattern("/a/b")
+
+	for _, path := range []string{"/a%2Fb", "/a%2fb/", "/x/a%2Fb"} {
+		if results, ok := m.FindNestedMatches(path); ok {
+			var got []string
+			for _, match := range results.Matches {
+				got = append(got, match.normalizedPattern)
+			}
+			t.Errorf("FindNestedMatches(%q) = %v, want no matches", path, got)
+		}
+	}
+
+	results, ok := m.FindNestedMatches("/a/b%2Fc")
+	if !ok || len(results.Matches) != 1 || results.Matches[0].normalizedPattern != "/a" {
+		t.Errorf("FindNestedMatches(/a/b%%2Fc) = %v, want only the /a prefix", results)
+	}
+}
+
+func TestCaseInsensitiveUnregisterAndConflicts(t *testing.T) {
+	m := New(&Options{Quiet: true, CaseInsensitive: true, Strict: true})
+	m.RegisterPattern("/About")
+
+	if _, err := m.TryRegisterPattern("/about"); err == nil {
+		t.Error("TryRegisterPattern(/about) should conflict with /About in case-insensitive strict mode")
+	}
+
+	if !m.UnregisterPattern("/About") {
+		t.Fatal("UnregisterPattern(/About) = false, want true")
+	}
+	if _, ok := m.FindBestMatch("/about"); ok {
+		t.Error("FindBestMatch(/about) still matches after unregistering")
+	}
+}
@@ -0,0 +1,153 @@
+package matcher
+
+import "strings"
+
+// Patterns may contain optional segments and alternation groups, which are
+// expanded at registration time into every equivalent concrete pattern:
+//
+//	"/docs/:version?/:page?" -> "/docs/:version/:page", "/docs/:version", "/docs"
+//	"/shop/(en|fr)?/cart"    -> "/shop/en/cart", "/shop/fr/cart", "/shop/cart"
+//	"/(en|fr)/about"         -> "/en/about", "/fr/about"
+//
+// Any segment ending in "?" is optional. A segment wrapped in parentheses is
+// a group of "|"-separated alternatives. An optional segment can only be
+// present if every optional segment before it is, so "/docs/:version?/:page?"
+// never expands to "/docs/:page". Every expanded form maps back to the same
+// OriginalPattern().
+
+// OptionalSegment describes one optional segment or alternation group from an
+// original pattern, as it appears in a particular expanded form.
+type OptionalSegment struct {
+	Original string // The segment as written in the original pattern, e.g. ":version?" or "(en|fr)?"
+	Present  bool   // Whether the segment is present in this expanded form
+	Value    string // The segment used in this expanded form, if present, e.g. ":version" or "en"
+}
+
+// OptionalSegments returns the optional segments and alternation groups of the
+// original pattern, in order, along with which of them are present in this
+// expanded form. Returns nil if the original pattern had neither.
+func (rp *RegisteredPattern) OptionalSegments() []OptionalSegment {
+	return rp.optionalSegments
+}
+
+type expandedPattern struct {
+	pattern          string
+	optionalSegments []OptionalSegment
+}
+
+type segmentChoice struct {
+	val     string
+	present bool
+}
+
+// expandOptionalSegments returns nil if the pattern has nothing to expand.
+// The first expanded form is always the one with every optional segment
+// present.
+func expandOptionalSegments(originalPattern string) []expandedPattern {
+	rawSegments := ParseSegments(originalPattern)
+
+	choicesPerSeg := make([][]segmentChoice, len(rawSegments))
+	isExpandable := make([]bool, len(rawSegments))
+	isOptional := make([]bool, len(rawSegments))
+	var hasAnyExpandable bool
+
+	for i, seg := range rawSegments {
+		choices, ok := getSegmentChoices(seg)
+		if ok {
+			hasAnyExpandable = true
+			isExpandable[i] = true
+			isOptional[i] = !choices[len(choices)-1].present
+			choicesPerSeg[i] = choices
+		} else {
+			choicesPerSeg[i] = []segmentChoice{{val: seg, present: true}}
+		}
+	}
+
+	if !hasAnyExpandable {
+		return nil
+	}
+
+	var results []expandedPattern
+	selected := make([]int, len(rawSegments))
+
+	for {
+		var sb strings.Builder
+		var optionalSegments []OptionalSegment
+		var sawAbsent, isGap bool
+
+		for i, choiceIdx := range selected {
+			choice := choicesPerSeg[i][choiceIdx]
+			if isOptional[i] {
+				isGap = isGap || (sawAbsent && choice.present)
+				sawAbsent = sawAbsent || !choice.present
+			}
+			if choice.present {
+				sb.WriteString("/")
+				sb.WriteString(choice.val)
+			}
+			if isExpandable[i] {
+				optionalSegments = append(optionalSegments, OptionalSegment{
+					Original: rawSegments[i],
+					Present:  choice.present,
+					Value:    choice.val,
+				})
+			}
+		}
+
+		// Skip forms where an optional segment is present after an absent one
+		if !isGap {
+			results = append(results, expandedPattern{
+				pattern:          sb.String(),
+				optionalSegments: optionalSegments,
+			})
+		}
+
+		// advance the odometer, rightmost segment first
+		i := len(selected) - 1
+		for ; i >= 0; i-- {
+			selected[i]++
+			if selected[i] < len(choicesPerSeg[i]) {
+				break
+			}
+			selected[i] = 0
+		}
+		if i < 0 {
+			break
+		}
+	}
+
+	return results
+}
+
+// getSegmentChoices returns the possible forms of a single raw segment,
+// present forms first, or false if the segment is neither optional nor a group.
+func getSegmentChoices(seg string) ([]segmentChoice, bool) {
+	isOptional := len(seg) > 1 && seg[len(seg)-1] == '?'
+	inner := seg
+	if isOptional {
+		inner = seg[:len(seg)-1]
+	}
+
+	var choices []segmentChoice
+
+	isGroup := len(inner) > 2 && inner[0] == '(' && inner[len(inner)-1] == ')'
+	if isGroup && (isOptional || strings.Contains(inner, "|")) {
+		for _, alt := range strings.Split(inner[1:len(inner)-1], "|") {
+			if alt != "" {
+				choices = append(choices, segmentChoice{val: alt, present: true})
+			}
+		}
+	} else if isOptional {
+		choices = append(choices, segmentChoice{val: inner, present: true})
+	}
+
+	if len(choices) == 0 {
+		return nil, false
+	}
+
+	if isOptional {
+		choices = append(choices, segmentChoice{present: false})
+	}
+
+	return choices, true
+}
@@ -0,0 +1,188 @@
+package matcher
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandOptionalSegments(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"/docs", nil},
+		{"/docs/:version?", []string{"/docs/:version", "/docs"}},
+		{"/docs/:version?/:page?", []string{"/docs/:version/:page", "/docs/:version", "/docs"}},
+		{"/:lang?/docs/:page?", []string{"/:lang/docs/:page", "/:lang/docs", "/docs"}},
+		{"/a/(x|y)?/(p|q)?", []string{"/a/x/p", "/a/x/q", "/a/x", "/a/y/p", "/a/y/q", "/a/y", "/a"}},
+		{"/shop/(en|fr)?/cart", []string{"/shop/en/cart", "/shop/fr/cart", "/shop/cart"}},
+		{"/(en|fr)/about", []string{"/en/about", "/fr/about"}},
+		{"/:lang?", []string{"/:lang", ""}},
+		{"/:lang?/", []string{"/:lang/", "/"}},
+		{"/wiki/(disambiguation)", nil},
+		{"/users/:id<int>?", []string{"/users/:id<int>", "/users"}},
+	}
+
+	for _, tt := range tests {
+		expanded := expandOptionalSegments(tt.pattern)
+		var got []string
+		for _, e := range expanded {
+			got = append(got, e.pattern)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("expandOptionalSegments(%q) = %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestFindBestMatchWithOptionalSegments(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	m.RegisterPattern("/docs/:version?/:page?")
+	m.RegisterPattern("/shop/(en|fr)?/cart")
+
+	tests := []struct {
+		path         string
+		wantPattern  string
+		wantParams   Params
+		wantOptional []OptionalSegment
+	}{
+		{
+			path:        "/docs",
+			wantPattern: "/docs",
+			wantOptional: []OptionalSegment{
+				{Original: ":version?", Present: false},
+				{Original: ":page?", Present: false},
+			},
+		},
+		{
+			path:        "/docs/v1",
+			wantPattern: "/docs/:version",
+			wantParams:  Params{"version": "v1"},
+			wantOptional: []OptionalSegment{
+				{Original: ":version?", Present: true, Value: ":version"},
+				{Original: ":page?", Present: false},
+			},
+		},
+		{
+			path:        "/docs/v1/intro",
+			wantPattern: "/docs/:version/:page",
+			wantParams:  Params{"version": "v1", "page": "intro"},
+			wantOptional: []OptionalSegment{
+				{Original: ":version?", Present: true, Value: ":version"},
+				{Original: ":page?", Present: true, Value: ":page"},
+			},
+		},
+		{
+			path:        "/shop/cart",
+			wantPattern: "/shop/cart",
+			wantOptional: []OptionalSegment{
+				{Original: "(en|fr)?", Present: false},
+			},
+		},
+		{
+			path:        "/shop/fr/cart",
+			wantPattern: "/shop/fr/cart",
+			wantOptional: []OptionalSegment{
+				{Original: "(en|fr)?", Present: true, Value: "fr"},
+			},
+		},
+		{
+			path:        "/shop/de/cart",
+			wantPattern: NOT_FOUND,
+		},
+	}
+
+	for _, tt := range tests {
+		match, ok := m.FindBestMatch(tt.path)
+		if tt.wantPattern == NOT_FOUND {
+			if ok {
+				t.Errorf("FindBestMatch(%q) = %q, want no match", tt.path, match.normalizedPattern)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("FindBestMatch(%q) found no match, want %q", tt.path, tt.wantPattern)
+			continue
+		}
+		if match.normalizedPattern != tt.wantPattern {
+			t.Errorf("FindBestMatch(%q) pattern = %q, want %q", tt.path, match.normalizedPattern, tt.wantPattern)
+		}
+		if !equalParams(match.Params, tt.wantParams) {
+			t.Errorf("FindBestMatch(%q) params = %v, want %v", tt.path, match.Params, tt.wantParams)
+		}
+		if !reflect.DeepEqual(match.OptionalSegments(), tt.wantOptional) {
+			t.Errorf("FindBestMatch(%q) optional segments = %+v, want %+v", tt.path, match.OptionalSegments(), tt.wantOptional)
+		}
+	}
+}
+
+func TestOptionalSegmentsShareOriginalPattern(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	first := m.RegisterPattern("/docs/:version?/:page?")
+
+	if first.normalizedPattern != "/docs/:version/:page" {
+		t.Errorf("RegisterPattern() returned %q, want the fully-present form", first.normalizedPattern)
+	}
+
+	for _, path := range []string{"/docs", "/docs/v1", "/docs/v1/intro"} {
+		match, ok := m.FindBestMatch(path)
+		if !ok {
+			t.Fatalf("FindBestMatch(%q) found no match", path)
+		}
+		if match.OriginalPattern() != "/docs/:version?/:page?" {
+			t.Errorf("FindBestMatch(%q) original pattern = %q, want %q", path, match.OriginalPattern(), "/docs/:version?/:page?")
+		}
+	}
+}
+
+func TestFindNestedMatchesWithOptionalSegments(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	m.RegisterPattern("/docs/:version?")
+	m.RegisterPattern("/docs/:version?/edit")
+
+	// Same-length forms of one pattern ("/docs/:version" and "/docs/:page")
+	// must resolve to the fuller one every time, as in FindBestMatch
+	twoOptional := New(&Options{Quiet: true})
+	twoOptional.RegisterPattern("/docs/:version?/:page?")
+
+	tests := []struct {
+		m            *Matcher
+		path         string
+		wantPatterns []string
+		wantParams   Params
+	}{
+		{m, "/docs", []string{"/docs"}, nil},
+		{m, "/docs/v1", []string{"/docs/:version"}, Params{"version": "v1"}},
+		{m, "/docs/edit", []string{"/docs", "/docs/edit"}, nil},
+		{m, "/docs/v1/edit", []string{"/docs/:version", "/docs/:version/edit"}, Params{"version": "v1"}},
+		{twoOptional, "/docs", []string{"/docs"}, nil},
+		{twoOptional, "/docs/v1", []string{"/docs/:version"}, Params{"version": "v1"}},
+		{twoOptional, "/docs/v1/intro", []string{"/docs/:version/:page"}, Params{"version": "v1", "page": "intro"}},
+	}
+
+	for _, tt := range tests {
+		for range 50 {
+			results, ok := tt.m.FindNestedMatches(tt.path)
+			if !ok {
+				t.Errorf("FindNestedMatches(%q) found no matches", tt.path)
+				break
+			}
+			var got []string
+			for _, match := range results.Matches {
+				got = append(got, match.normalizedPattern)
+			}
+			if !reflect.DeepEqual(got, tt.wantPatterns) {
+				t.Errorf("FindNestedMatches(%q) patterns = %v, want %v", tt.path, got, tt.wantPatterns)
+				break
+			}
+			if !equalParams(results.Params, tt.wantParams) {
+				t.Errorf("FindNestedMatches(%q) params = %v, want %v", tt.path, results.Params, tt.wantParams)
+				break
+			}
+		}
+
+		if best, ok := tt.m.FindBestMatch(tt.path); !ok || best.normalizedPattern != tt.wantPatterns[len(tt.wantPatterns)-1] {
+			t.Errorf("FindBestMatch(%q) disagrees with FindNestedMatches: %v", tt.path, best)
+		}
+	}
+}
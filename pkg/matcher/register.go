@@ -6,14 +6,17 @@ import (
 	"strings"
 )
 
-// Note -- __TODO should we validate that there are no two competing dynamic segments in otherwise matching patterns?
-
 const (
-	nodeStatic       uint8 = 0
-	nodeDynamic      uint8 = 1
-	nodeSplat        uint8 = 2
-	scoreStaticMatch       = 2
-	scoreDynamic           = 1
+	nodeStatic   uint8 = 0
+	nodeDynamic  uint8 = 1
+	nodeSplat    uint8 = 2
+	nodeWildcard uint8 = 3
+	nodeGlobstar uint8 = 4
+
+	scoreStaticMatch        = 4
+	scoreAffix              = 3 // dynamic param or wildcard with a static prefix and/or suffix
+	scoreConstrainedDynamic = 2
+	scoreDynamic            = 1
 )
 
 type RegisteredPattern struct {
@@ -24,6 +27,9 @@ type RegisteredPattern struct {
 	lastSegIsNonRootSplat    bool
 	lastSegIsIndex           bool
 	numberOfDynamicParamSegs uint8
+	optionalSegments         []OptionalSegment
+	hasComplexSegments       bool   // has wildcards, globstars, or affix segments
+	host                     string // normalized host part of a host-qualified pattern
 }
 
 func (rp *RegisteredPattern) NormalizedPattern() string {
@@ -38,6 +44,10 @@ func (rp *RegisteredPattern) OriginalPattern() string {
 	return rp.originalPattern
 }
 
+func (rp *RegisteredPattern) LastSegIsIndex() bool {
+	return rp.lastSegIsIndex
+}
+
 func HasTrailingSlash(pattern string) bool {
 	return len(pattern) > 0 && pattern[len(pattern)-1] == '/'
 }
@@ -67,18 +77,28 @@ func JoinPatterns(rp *RegisteredPattern, pattern string) string {
 type segment struct {
 	normalizedVal string
 	segType       segType
+	paramName     string
+	constraint    *paramConstraint
+	lookupVal     string // key in the parent node's children map (static and index segments only)
+	prefix        string // static prefix of an affix segment, e.g. "v" in "v:version"
+	suffix        string // static suffix of an affix segment, e.g. ".png" in ":name.png"
+	literal       string // unescaped value of a static segment, e.g. "a:b" for `a\:b`
 }
 
 var segTypes = struct {
-	splat   segType
-	static  segType
-	dynamic segType
-	index   segType
+	splat    segType
+	static   segType
+	dynamic  segType
+	index    segType
+	wildcard segType
+	globstar segType
 }{
-	splat:   "splat",
-	static:  "static",
-	dynamic: "dynamic",
-	index:   "index",
+	splat:    "splat",
+	static:   "static",
+	dynamic:  "dynamic",
+	index:    "index",
+	wildcard: "wildcard",
+	globstar: "globstar",
 }
 
 func getAppropriateWarningMsg(pattern string, usingExplicitIndexSegment bool) string {
@@ -120,23 +140,23 @@ func (m *Matcher) NormalizePattern(originalPattern string) *RegisteredPattern {
 	segments := make([]*segment, 0, len(rawSegments))
 
 	var numberOfDynamicParamSegs uint8
+	var hasComplexSegments bool
 
-	for _, seg := range rawSegments {
-		normalizedVal := seg
-
-		segType := m.getSegmentTypeAssumeNormalized(seg)
-		if segType == segTypes.dynamic {
+	for i, raw := range rawSegments {
+		seg := m.parseSegment(raw, i == len(rawSegments)-1)
+		if seg.segType == segTypes.dynamic {
 			numberOfDynamicParamSegs++
-			normalizedVal = ":" + seg[1:]
 		}
-		if segType == segTypes.splat {
-			normalizedVal = "*"
+		if seg.isComplex() {
+			hasComplexSegments = true
 		}
 
-		segments = append(segments, &segment{
-			normalizedVal: normalizedVal,
-			segType:       segType,
-		})
+		seg.lookupVal = seg.normalizedVal
+		if seg.segType == segTypes.static {
+			seg.lookupVal = m.staticKey(seg.literal)
+		}
+
+		segments = append(segments, seg)
 	}
 
 	segLen := len(segments)
@@ -168,21 +188,85 @@ func (m *Matcher) NormalizePattern(originalPattern string) *RegisteredPattern {
 		lastSegIsNonRootSplat:    lastType == segTypes.splat && segLen > 1,
 		lastSegIsIndex:           lastType == segTypes.index,
 		numberOfDynamicParamSegs: numberOfDynamicParamSegs,
+		hasComplexSegments:       hasComplexSegments,
 	}
 }
 
+// RegisterPattern registers a pattern and returns its normalized form. If the
+// pattern contains optional segments or alternation groups, every expanded
+// form is registered, and the form with all optional segments present is
+// returned. In strict mode, RegisterPattern panics if the pattern conflicts
+// with an already registered pattern (see TryRegisterPattern).
 func (m *Matcher) RegisterPattern(originalPattern string) *RegisteredPattern {
-	n := m.NormalizePattern(originalPattern)
+	rp, err := m.TryRegisterPattern(originalPattern)
+	if err != nil {
+		panic(err)
+	}
+	return rp
+}
+
+// TryRegisterPattern is like RegisterPattern, but returns an error instead of
+// panicking when strict mode rejects the pattern. Nothing is registered if an
+// error is returned. Outside of strict mode, the error is always nil.
+func (m *Matcher) TryRegisterPattern(originalPattern string) (*RegisteredPattern, error) {
+	if host, path, ok := SplitHostPattern(originalPattern); ok {
+		return m.registerHostPattern(originalPattern, host, path)
+	}
+	return m.tryRegister(originalPattern, originalPattern)
+}
+
+// tryRegister registers a path pattern under the given original pattern,
+// which differs from the path pattern only for host-qualified patterns.
+func (m *Matcher) tryRegister(originalPattern, pathPattern string) (*RegisteredPattern, error) {
+	rps := m.normalizeWithExpansions(originalPattern, pathPattern)
+
+	if m.strict {
+		for i, n := range rps {
+			if err := m.checkConflicts(n, rps[:i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, n := range rps {
+		m.registerNormalized(n)
+	}
+	m.originalPatterns[originalPattern] = rps
+
+	return rps[0], nil
+}
+
+func (m *Matcher) normalizeWithExpansions(originalPattern, pathPattern string) []*RegisteredPattern {
+	expanded := expandOptionalSegments(pathPattern)
+	if expanded == nil {
+		n := m.NormalizePattern(pathPattern)
+		n.originalPattern = originalPattern
+		return []*RegisteredPattern{n}
+	}
+
+	rps := make([]*RegisteredPattern, 0, len(expanded))
+	for _, e := range expanded {
+		n := m.NormalizePattern(e.pattern)
+		n.originalPattern = originalPattern
+		n.optionalSegments = e.optionalSegments
+		rps = append(rps, n)
+	}
+	return rps
+}
 
-	if _, alreadyRegistered := m.staticPatterns[n.normalizedPattern]; alreadyRegistered {
-		m.Log(getAppropriateWarningMsg(originalPattern, m.usingExplicitIndexSegment))
+func (m *Matcher) registerNormalized(n *RegisteredPattern) *RegisteredPattern {
+	if _, alreadyRegistered := m.findStatic(n.normalizedPattern); alreadyRegistered {
+		m.Log(getAppropriateWarningMsg(n.originalPattern, m.usingExplicitIndexSegment))
 	}
 	if _, alreadyRegistered := m.dynamicPatterns[n.normalizedPattern]; alreadyRegistered {
-		m.Log(getAppropriateWarningMsg(originalPattern, m.usingExplicitIndexSegment))
+		m.Log(getAppropriateWarningMsg(n.originalPattern, m.usingExplicitIndexSegment))
 	}
 
 	if getIsStatic(n.normalizedSegments) {
 		m.staticPatterns[n.normalizedPattern] = n
+		if m.foldsStatic {
+			m.foldedStaticPatterns[m.staticKey(n.normalizedPattern)] = n
+		}
 		return n
 	}
 
@@ -192,13 +276,8 @@ func (m *Matcher) RegisterPattern(originalPattern string) *RegisteredPattern {
 	var nodeScore int
 
 	for i, segment := range n.normalizedSegments {
-		child := current.findOrCreateChild(segment.normalizedVal)
-		switch {
-		case segment.segType == segTypes.dynamic:
-			nodeScore += scoreDynamic
-		case segment.segType != segTypes.splat:
-			nodeScore += scoreStaticMatch
-		}
+		child := current.findOrCreateChild(segment)
+		nodeScore += getSegmentScore(segment)
 
 		if i == len(n.normalizedSegments)-1 {
 			child.finalScore = nodeScore
@@ -211,26 +290,33 @@ func (m *Matcher) RegisterPattern(originalPattern string) *RegisteredPattern {
 	return n
 }
 
-func (m *Matcher) getSegmentTypeAssumeNormalized(segment string) segType {
-	switch {
-	case segment == "":
-		return segTypes.index
-	case len(segment) == 1 && segment == string(m.splatSegmentRune):
-		return segTypes.splat
-	case len(segment) > 0 && segment[0] == byte(m.dynamicParamPrefixRune):
-		return segTypes.dynamic
-	default:
-		return segTypes.static
+func getSegmentScore(seg *segment) int {
+	switch seg.segType {
+	case segTypes.static, segTypes.index:
+		return scoreStaticMatch
+	case segTypes.dynamic, segTypes.wildcard:
+		switch {
+		case seg.hasAffix():
+			return scoreAffix
+		case seg.constraint != nil:
+			return scoreConstrainedDynamic
+		}
+		return scoreDynamic
 	}
+	return 0
 }
 
 func getIsStatic(segments []*segment) bool {
 	if len(segments) > 0 {
 		for _, segment := range segments {
-			switch segment.segType {
-			case segTypes.splat:
+			// Escaped static segments can't be keyed by the normalized
+			// pattern, so they are matched through the trie
+			if segment.literal != segment.normalizedVal {
 				return false
-			case segTypes.dynamic:
+			}
+			switch segment.segType {
+			case segTypes.static, segTypes.index:
+			default:
 				return false
 			}
 		}
@@ -244,40 +330,85 @@ type segmentNode struct {
 	children    map[string]*segmentNode
 	dynChildren []*segmentNode
 	paramName   string
+	constraint  *paramConstraint
+	prefix      string
+	suffix      string
+	segScore    int // score of this node's own segment
 	finalScore  int
 }
 
-// findOrCreateChild finds or creates a child node for a segment
-func (n *segmentNode) findOrCreateChild(segment string) *segmentNode {
-	if segment == "*" || (len(segment) > 0 && rune(segment[0]) == ':') {
+// findChild finds the child node for a segment, or returns nil if there is none
+func (n *segmentNode) findChild(seg *segment) *segmentNode {
+	if isDynamicSegType(seg.segType) {
+		nodeType := nodeTypeForSegType(seg.segType)
 		for _, child := range n.dynChildren {
-			if child.paramName == segment[1:] {
+			if child.nodeType != nodeType {
+				continue
+			}
+			if child.paramName == seg.paramName &&
+				child.constraint.rawOrEmpty() == seg.constraint.rawOrEmpty() &&
+				child.prefix == seg.prefix &&
+				child.suffix == seg.suffix {
 				return child
 			}
 		}
-		return n.addDynamicChild(segment)
+		return nil
+	}
+	return n.children[seg.lookupVal]
+}
+
+// findOrCreateChild finds or creates a child node for a segment
+func (n *segmentNode) findOrCreateChild(seg *segment) *segmentNode {
+	if child := n.findChild(seg); child != nil {
+		return child
+	}
+
+	if isDynamicSegType(seg.segType) {
+		return n.addDynamicChild(seg)
 	}
 
 	if n.children == nil {
 		n.children = make(map[string]*segmentNode)
 	}
-	if child, exists := n.children[segment]; exists {
-		return child
-	}
-	child := &segmentNode{nodeType: nodeStatic}
-	n.children[segment] = child
+	child := &segmentNode{nodeType: nodeStatic, segScore: scoreStaticMatch}
+	n.children[seg.lookupVal] = child
 	return child
 }
 
-// addDynamicChild creates a new dynamic or splat child node
-func (n *segmentNode) addDynamicChild(segment string) *segmentNode {
-	child := &segmentNode{}
-	if segment == "*" {
-		child.nodeType = nodeSplat
-	} else {
-		child.nodeType = nodeDynamic
-		child.paramName = segment[1:]
+// addDynamicChild creates a new dynamic, splat, wildcard, or globstar child node
+func (n *segmentNode) addDynamicChild(seg *segment) *segmentNode {
+	child := &segmentNode{
+		nodeType:   nodeTypeForSegType(seg.segType),
+		paramName:  seg.paramName,
+		constraint: seg.constraint,
+		prefix:     seg.prefix,
+		suffix:     seg.suffix,
+		segScore:   getSegmentScore(seg),
 	}
 	n.dynChildren = append(n.dynChildren, child)
 	return child
 }
+
+// isDynamicSegType reports whether segments of the given type live in a
+// node's dynChildren rather than its static children map.
+func isDynamicSegType(t segType) bool {
+	switch t {
+	case segTypes.dynamic, segTypes.splat, segTypes.wildcard, segTypes.globstar:
+		return true
+	}
+	return false
+}
+
+func nodeTypeForSegType(t segType) uint8 {
+	switch t {
+	case segTypes.splat:
+		return nodeSplat
+	case segTypes.wildcard:
+		return nodeWildcard
+	case segTypes.globstar:
+		return nodeGlobstar
+	case segTypes.dynamic:
+		return nodeDynamic
+	}
+	return nodeStatic
+}
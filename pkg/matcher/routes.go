@@ -0,0 +1,75 @@
+package matcher
+
+import (
+	"slices"
+	"strings"
+)
+
+// RouteInfo describes a single registered (and, if applicable, expanded) pattern.
+type RouteInfo struct {
+	Pattern         string // Normalized pattern, including the host part (if any)
+	OriginalPattern string // Pattern as originally registered
+	Host            string // Normalized host part of a host-qualified pattern
+	IsStatic        bool
+	Score           int // Sum of per-segment specificity scores; higher is more specific
+	Segments        []SegmentInfo
+}
+
+// SegmentInfo describes a single segment of a registered pattern.
+type SegmentInfo struct {
+	Value      string // Normalized segment value, e.g. "users", ":id<int>", ":name.png", "*", or "**"
+	Type       string // One of "static", "dynamic", "splat", "index", "wildcard", or "globstar"
+	ParamName  string // Only set for dynamic segments
+	Constraint string // Only set for constrained dynamic segments
+	Score      int
+}
+
+// Routes returns every registered pattern, sorted by normalized pattern.
+// Host-qualified patterns are included in their "//host/path" form.
+func (m *Matcher) Routes() []RouteInfo {
+	routes := make([]RouteInfo, 0, len(m.staticPatterns)+len(m.dynamicPatterns))
+
+	for _, rp := range m.staticPatterns {
+		routes = append(routes, toRouteInfo(rp, true))
+	}
+	for _, rp := range m.dynamicPatterns {
+		routes = append(routes, toRouteInfo(rp, false))
+	}
+	for _, hr := range m.hostRoutes {
+		routes = append(routes, hr.matcher.Routes()...)
+	}
+
+	slices.SortFunc(routes, func(a, b RouteInfo) int {
+		return strings.Compare(a.Pattern, b.Pattern)
+	})
+
+	return routes
+}
+
+func toRouteInfo(rp *RegisteredPattern, isStatic bool) RouteInfo {
+	info := RouteInfo{
+		Pattern:         rp.normalizedPattern,
+		OriginalPattern: rp.originalPattern,
+		Host:            rp.host,
+		IsStatic:        isStatic,
+		Segments:        make([]SegmentInfo, 0, len(rp.normalizedSegments)),
+	}
+
+	if rp.host != "" {
+		info.Pattern = "//" + rp.host + rp.normalizedPattern
+	}
+
+	for _, seg := range rp.normalizedSegments {
+		segScore := getSegmentScore(seg)
+		info.Score += segScore
+		info.Segments = append(info.Segments, SegmentInfo{
+			Value:      seg.normalizedVal,
+			Type:       seg.segType,
+			ParamName:  seg.paramName,
+			Constraint: seg.constraint.rawOrEmpty(),
+			Score:      segScore,
+		})
+	}
+
+	return info
+}
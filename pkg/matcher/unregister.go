@@ -0,0 +1,157 @@
+package matcher
+
+import (
+	"maps"
+	"slices"
+)
+
+// UnregisterPattern removes a previously registered pattern, including every
+// expanded form of it, and prunes any trie nodes that are no longer needed.
+// The pattern may be given either as originally registered or in its
+// normalized form. Returns false if the pattern was not registered.
+func (m *Matcher) UnregisterPattern(pattern string) bool {
+	if host, path, ok := SplitHostPattern(pattern); ok {
+		hr, ok := m.hostRoutesByHost[joinLabels(m.parseHost(host))]
+		if !ok {
+			return false
+		}
+		if _, ok := hr.matcher.originalPatterns[pattern]; ok {
+			return hr.matcher.unregister(pattern)
+		}
+		return hr.matcher.unregister(path)
+	}
+	return m.unregister(pattern)
+}
+
+func (m *Matcher) unregister(pattern string) bool {
+	if rps, ok := m.originalPatterns[pattern]; ok {
+		delete(m.originalPatterns, pattern)
+		for _, rp := range rps {
+			m.unregisterNormalized(rp)
+		}
+		return true
+	}
+
+	rp, ok := m.staticPatterns[pattern]
+	if !ok {
+		rp, ok = m.dynamicPatterns[pattern]
+	}
+	if !ok {
+		return false
+	}
+
+	m.unregisterNormalized(rp)
+
+	// Keep the original pattern index consistent
+	rps := slices.DeleteFunc(slices.Clone(m.originalPatterns[rp.originalPattern]), func(x *RegisteredPattern) bool {
+		return x == rp
+	})
+	if len(rps) == 0 {
+		delete(m.originalPatterns, rp.originalPattern)
+	} else {
+		m.originalPatterns[rp.originalPattern] = rps
+	}
+
+	return true
+}
+
+func (m *Matcher) unregisterNormalized(rp *RegisteredPattern) {
+	// Only remove the pattern if it hasn't since been overwritten by a later
+	// registration that normalizes to the same thing.
+	if m.staticPatterns[rp.normalizedPattern] == rp {
+		delete(m.staticPatterns, rp.normalizedPattern)
+		if m.foldsStatic && m.foldedStaticPatterns[m.staticKey(rp.normalizedPattern)] == rp {
+			delete(m.foldedStaticPatterns, m.staticKey(rp.normalizedPattern))
+		}
+		return
+	}
+	if m.dynamicPatterns[rp.normalizedPattern] == rp {
+		delete(m.dynamicPatterns, rp.normalizedPattern)
+		m.rootNode.removePattern(rp.normalizedSegments, 0, rp.normalizedPattern)
+	}
+}
+
+// removePattern clears the pattern from the node at the end of the given
+// segments, then reports whether this node is now empty and can be pruned
+// from its parent.
+func (n *segmentNode) removePattern(segments []*segment, depth int, pattern string) bool {
+	if depth == len(segments) {
+		if n.pattern == pattern {
+			n.pattern = ""
+			n.finalScore = 0
+		}
+		return n.isEmpty()
+	}
+
+	seg := segments[depth]
+	child := n.findChild(seg)
+	if child == nil {
+		return false
+	}
+
+	if child.removePattern(segments, depth+1, pattern) {
+		if isDynamicSegType(seg.segType) {
+			n.dynChildren = slices.DeleteFunc(n.dynChildren, func(c *segmentNode) bool {
+				return c == child
+			})
+			if len(n.dynChildren) == 0 {
+				n.dynChildren = nil
+			}
+		} else {
+			delete(n.children, seg.lookupVal)
+			if len(n.children) == 0 {
+				n.children = nil
+			}
+		}
+	}
+
+	return n.isEmpty()
+}
+
+func (n *segmentNode) isEmpty() bool {
+	return n.pattern == "" && len(n.children) == 0 && len(n.dynChildren) == 0
+}
+
+// Clone returns a deep copy of the matcher's route table. Registered patterns
+// themselves are immutable and are shared between the copies.
+func (m *Matcher) Clone() *Matcher {
+	clone := *m
+
+	clone.staticPatterns = maps.Clone(m.staticPatterns)
+	clone.foldedStaticPatterns = maps.Clone(m.foldedStaticPatterns)
+	clone.dynamicPatterns = maps.Clone(m.dynamicPatterns)
+	clone.originalPatterns = maps.Clone(m.originalPatterns)
+	clone.rootNode = m.rootNode.clone()
+
+	if m.hostRoutes != nil {
+		clone.hostRoutes = make([]*hostRoute, len(m.hostRoutes))
+		clone.hostRoutesByHost = make(map[string]*hostRoute, len(m.hostRoutes))
+		for i, hr := range m.hostRoutes {
+			hrClone := *hr
+			hrClone.matcher = hr.matcher.Clone()
+			clone.hostRoutes[i] = &hrClone
+			clone.hostRoutesByHost[hr.host] = &hrClone
+		}
+	}
+
+	return &clone
+}
+
+func (n *segmentNode) clone() *segmentNode {
+	clone := *n
+
+	if n.children != nil {
+		clone.children = make(map[string]*segmentNode, len(n.children))
+		for k, child := range n.children {
+			clone.children[k] = child.clone()
+		}
+	}
+	if n.dynChildren != nil {
+		clone.dynChildren = make([]*segmentNode, len(n.dynChildren))
+		for i, child := range n.dynChildren {
+			clone.dynChildren[i] = child.clone()
+		}
+	}
+
+	return &clone
+}
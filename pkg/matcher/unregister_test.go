@@ -0,0 +1,141 @@
+package matcher
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestUnregisterPattern(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	m.RegisterPattern("/")
+	m.RegisterPattern("/users")
+	m.RegisterPattern("/users/:id")
+	m.RegisterPattern("/users/:id/posts")
+	m.RegisterPattern("/files/*")
+	m.RegisterPattern("/docs/:version?")
+
+	if !m.UnregisterPattern("/users/:id") {
+		t.Fatal("UnregisterPattern(/users/:id) = false, want true")
+	}
+	if m.UnregisterPattern("/users/:id") {
+		t.Error("second UnregisterPattern(/users/:id) = true, want false")
+	}
+	if _, ok := m.FindBestMatch("/users/123"); ok {
+		t.Error("FindBestMatch(/users/123) still matches after unregistering")
+	}
+	if match, ok := m.FindBestMatch("/users/123/posts"); !ok || match.normalizedPattern != "/users/:id/posts" {
+		t.Error("FindBestMatch(/users/123/posts) should still match the child pattern")
+	}
+
+	// Expanded forms are all removed together
+	if !m.UnregisterPattern("/docs/:version?") {
+		t.Fatal("UnregisterPattern(/docs/:version?) = false, want true")
+	}
+	for _, path := range []string{"/docs", "/docs/v1"} {
+		if _, ok := m.FindBestMatch(path); ok {
+			t.Errorf("FindBestMatch(%s) still matches after unregistering", path)
+		}
+	}
+
+	// Normalized form works too
+	if !m.UnregisterPattern("/files/*") {
+		t.Fatal("UnregisterPattern(/files/*) = false, want true")
+	}
+	if _, ok := m.FindBestMatch("/files/a/b"); ok {
+		t.Error("FindBestMatch(/files/a/b) still matches after unregistering")
+	}
+
+	if !m.UnregisterPattern("/users/:id/posts") || !m.UnregisterPattern("/users") || !m.UnregisterPattern("/") {
+		t.Fatal("UnregisterPattern() = false for a registered pattern")
+	}
+
+	if len(m.staticPatterns) != 0 || len(m.dynamicPatterns) != 0 || len(m.originalPatterns) != 0 {
+		t.Errorf("maps not empty after unregistering everything: %v %v %v", m.staticPatterns, m.dynamicPatterns, m.originalPatterns)
+	}
+	if !m.rootNode.isEmpty() {
+		t.Errorf("trie not pruned after unregistering everything: %+v", m.rootNode)
+	}
+}
+
+func TestUnregisterPatternKeepsSharedPrefix(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	m.RegisterPattern("/a/:b")
+	m.RegisterPattern("/a/:b/c/:d")
+
+	m.UnregisterPattern("/a/:b/c/:d")
+
+	bNode := m.rootNode.children["a"].dynChildren[0]
+	if bNode.pattern != "/a/:b" {
+		t.Errorf("shared prefix node lost its pattern: %q", bNode.pattern)
+	}
+	if len(bNode.children) != 0 {
+		t.Errorf("dead branch was not pruned: %+v", bNode.children)
+	}
+}
+
+func TestClone(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	m.RegisterPattern("/users/:id")
+
+	clone := m.Clone()
+	clone.RegisterPattern("/posts/:id")
+	clone.UnregisterPattern("/users/:id")
+
+	if _, ok := m.FindBestMatch("/users/1"); !ok {
+		t.Error("original lost /users/:id after editing the clone")
+	}
+	if _, ok := m.FindBestMatch("/posts/1"); ok {
+		t.Error("original gained /posts/:id after editing the clone")
+	}
+	if _, ok := clone.FindBestMatch("/posts/1"); !ok {
+		t.Error("clone is missing /posts/:id")
+	}
+}
+
+func TestAtomicMatcherConcurrentUpdates(t *testing.T) {
+	a := NewAtomic(New(&Options{Quiet: true}))
+	a.Update(func(m *Matcher) {
+		m.RegisterPattern("/stable/:id")
+	})
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if _, ok := a.FindBestMatch("/stable/1"); !ok {
+					t.Error("FindBestMatch(/stable/1) failed during concurrent updates")
+					return
+				}
+				a.FindNestedMatches("/dynamic/1/2")
+			}
+		}()
+	}
+
+	for i := range 100 {
+		a.Update(func(m *Matcher) {
+			m.UnregisterPattern(fmt.Sprintf("/dynamic/%d/:id", i-1))
+			m.RegisterPattern(fmt.Sprintf("/dynamic/%d/:id", i))
+		})
+	}
+
+	fresh := New(&Options{Quiet: true})
+	fresh.RegisterPattern("/stable/:id")
+	a.Swap(fresh)
+
+	close(stop)
+	wg.Wait()
+
+	if _, ok := a.FindBestMatch("/dynamic/99/x"); ok {
+		t.Error("swapped-out table is still in use")
+	}
+}
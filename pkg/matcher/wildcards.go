@@ -0,0 +1,292 @@
+package matcher
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// In addition to static, dynamic, and trailing splat segments, patterns may
+// contain:
+//
+//   - Mid-path wildcards, e.g. "/files/*/raw", where "*" matches exactly one
+//     segment.
+//   - Globstars, e.g. "/assets/**/raw", where "**" matches zero or more segments.
+//   - Affix segments, which pair a dynamic param or a wildcard with a static
+//     prefix and/or suffix, e.g. "/img/:name.png", "/v:version/api", or
+//     "/assets/**/*.map".
+//
+// A bare "*" as the last segment keeps its traditional splat meaning (the rest
+// of the path). Values matched by wildcards and globstars are reported, in
+// order, in SplatValues. For an affix wildcard, only the part between the
+// prefix and suffix is reported. Param names in affix segments consist of
+// letters, digits, underscores, and hyphens; the suffix starts at the first
+// character that can't be part of a param name (or after a constraint). A
+// param prefix rune must be followed by a name; "/v:" is rejected.
+//
+// Breaking change: a segment that merely contains the param prefix rune or
+// the splat rune used to be static, so "/things:batchGet" and "/file*" were
+// literal paths. They are now an affix param and an affix wildcard. To keep
+// such a character literal, escape it with a backslash, e.g.
+// "/things\:batchGet" or "/file\*". A backslash before any other character is
+// itself literal.
+
+// parseSegment parses a single raw pattern segment into its normalized form.
+func (m *Matcher) parseSegment(raw string, isLast bool) *segment {
+	paramRune := string(m.dynamicParamPrefixRune)
+	splatRune := string(m.splatSegmentRune)
+
+	switch {
+	case raw == "":
+		return &segment{segType: segTypes.index}
+
+	case raw == splatRune:
+		if isLast {
+			return &segment{normalizedVal: "*", segType: segTypes.splat}
+		}
+		return &segment{normalizedVal: "*", segType: segTypes.wildcard}
+
+	case raw == splatRune+splatRune:
+		return &segment{normalizedVal: "**", segType: segTypes.globstar}
+	}
+
+	if idx := m.indexUnescaped(raw, paramRune); idx != -1 {
+		return m.parseParamSegment(raw, raw[:idx], raw[idx+len(paramRune):])
+	}
+
+	if idx := m.indexUnescaped(raw, splatRune); idx != -1 {
+		prefix, suffix := raw[:idx], raw[idx+len(splatRune):]
+		if m.indexUnescaped(suffix, splatRune) == -1 {
+			return &segment{
+				normalizedVal: prefix + "*" + suffix,
+				segType:       segTypes.wildcard,
+				prefix:        m.unescape(prefix),
+				suffix:        m.unescape(suffix),
+			}
+		}
+	}
+
+	return &segment{normalizedVal: raw, segType: segTypes.static, literal: m.unescape(raw)}
+}
+
+// parseParamSegment parses what follows the param prefix rune: a param name,
+// an optional constraint, and an optional static suffix.
+func (m *Matcher) parseParamSegment(raw, prefix, rest string) *segment {
+	nameEnd := 0
+	for nameEnd < len(rest) && isParamNameByte(rest[nameEnd]) {
+		nameEnd++
+	}
+	if nameEnd == 0 {
+		panic(fmt.Sprintf("matcher: empty param name in segment '%s'", raw))
+	}
+	name := rest[:nameEnd]
+	rest = rest[nameEnd:]
+
+	var rawConstraint string
+	if len(rest) > 0 && rest[0] == constraintOpen {
+		if closeIdx := strings.LastIndexByte(rest, constraintClose); closeIdx > 0 {
+			rawConstraint = rest[1:closeIdx]
+			rest = rest[closeIdx+1:]
+		}
+	}
+
+	constraint := newParamConstraint(rawConstraint)
+
+	var sb strings.Builder
+	sb.WriteString(prefix)
+	sb.WriteString(":")
+	sb.WriteString(name)
+	if constraint != nil {
+		sb.WriteByte(constraintOpen)
+		sb.WriteString(constraint.raw)
+		sb.WriteByte(constraintClose)
+	}
+	sb.WriteString(rest)
+
+	return &segment{
+		normalizedVal: sb.String(),
+		segType:       segTypes.dynamic,
+		paramName:     name,
+		constraint:    constraint,
+		prefix:        m.unescape(prefix),
+		suffix:        m.unescape(rest),
+	}
+}
+
+// indexUnescaped returns the index of the first occurrence of r in s that is
+// not preceded by a backslash, or -1.
+func (m *Matcher) indexUnescaped(s, r string) int {
+	for offset := 0; ; {
+		idx := strings.Index(s[offset:], r)
+		if idx == -1 {
+			return -1
+		}
+		idx += offset
+		if idx == 0 || s[idx-1] != '\\' {
+			return idx
+		}
+		offset = idx + len(r)
+	}
+}
+
+// unescape removes the backslashes that escape a param prefix rune or a splat
+// rune, leaving any other backslash alone.
+func (m *Matcher) unescape(s string) string {
+	if strings.IndexByte(s, '\\') == -1 {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			next, _ := utf8.DecodeRuneInString(s[i+1:])
+			if next == m.dynamicParamPrefixRune || next == m.splatSegmentRune {
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+func isParamNameByte(b byte) bool {
+	return isAlphaByte(b) || isDigitByte(b) || b == '_' || b == '-'
+}
+
+func (seg *segment) hasAffix() bool {
+	return seg.prefix != "" || seg.suffix != ""
+}
+
+// isComplex reports whether the segment is something only the wildcard-aware
+// binding logic knows how to extract values from.
+func (seg *segment) isComplex() bool {
+	return seg.hasAffix() || seg.segType == segTypes.wildcard || seg.segType == segTypes.globstar
+}
+
+// matchInner checks a real (lookup) segment against a dynamic or wildcard
+// segment, returning the part between the prefix and suffix.
+func (m *Matcher) matchInner(seg string, prefix, suffix string, constraint *paramConstraint) (string, bool) {
+	if seg == "" {
+		return "", false
+	}
+	inner, ok := m.trimAffix(seg, prefix, suffix)
+	if !ok || !constraint.allows(inner) {
+		return "", false
+	}
+	return inner, true
+}
+
+// trimAffix strips the given prefix and suffix from seg, requiring something
+// non-empty to remain. Affixes are compared case-insensitively when the
+// matcher is.
+func (m *Matcher) trimAffix(seg, prefix, suffix string) (string, bool) {
+	if prefix == "" && suffix == "" {
+		return seg, true
+	}
+	if len(seg) <= len(prefix)+len(suffix) {
+		return "", false
+	}
+	head, tail := seg[:len(prefix)], seg[len(seg)-len(suffix):]
+	if m.caseInsensitive {
+		if !strings.EqualFold(head, prefix) || !strings.EqualFold(tail, suffix) {
+			return "", false
+		}
+	} else if head != prefix || tail != suffix {
+		return "", false
+	}
+	return seg[len(prefix) : len(seg)-len(suffix)], true
+}
+
+// realInner returns the part of the real segment corresponding to an already
+// matched lookup inner value. The two only differ when a matching mode such as
+// DecodePercentEncoding is in use.
+func (m *Matcher) realInner(realSeg, lookupInner, prefix, suffix string) string {
+	if inner, ok := m.trimAffix(realSeg, prefix, suffix); ok {
+		return inner
+	}
+	return lookupInner
+}
+
+// bindSegments matches the segments of a pattern against a real path,
+// returning the params and splat values. It is only used for patterns with
+// complex segments, where pattern segments and real segments aren't aligned
+// one to one.
+func (m *Matcher) bindSegments(rp *RegisteredPattern, segments, realSegments []string) (Params, []string, bool) {
+	var params Params
+	if rp.numberOfDynamicParamSegs > 0 {
+		params = make(Params, rp.numberOfDynamicParamSegs)
+	}
+	var splatValues []string
+	if m.bindFrom(rp.normalizedSegments, segments, realSegments, 0, 0, params, &splatValues) {
+		return params, splatValues, true
+	}
+	return nil, nil, false
+}
+
+func (m *Matcher) bindFrom(
+	patternSegs []*segment,
+	segments, realSegments []string,
+	i, j int,
+	params Params,
+	splatValues *[]string,
+) bool {
+	if i == len(patternSegs) {
+		return j == len(segments) || (j == len(segments)-1 && segments[j] == "")
+	}
+
+	seg := patternSegs[i]
+	splatLen := len(*splatValues)
+
+	switch seg.segType {
+	case segTypes.splat:
+		*splatValues = append(*splatValues, realSegments[min(j, len(realSegments)):]...)
+		return true
+
+	case segTypes.globstar:
+		for next := j; next <= len(segments); next++ {
+			*splatValues = append((*splatValues)[:splatLen], realSegments[j:next]...)
+			if m.bindFrom(patternSegs, segments, realSegments, i+1, next, params, splatValues) {
+				return true
+			}
+		}
+		*splatValues = (*splatValues)[:splatLen]
+		return false
+	}
+
+	if j >= len(segments) {
+		return false
+	}
+
+	switch seg.segType {
+	case segTypes.static, segTypes.index:
+		if m.staticKey(segments[j]) != seg.lookupVal {
+			return false
+		}
+		return m.bindFrom(patternSegs, segments, realSegments, i+1, j+1, params, splatValues)
+
+	case segTypes.dynamic:
+		inner, ok := m.matchInner(segments[j], seg.prefix, seg.suffix, seg.constraint)
+		if !ok {
+			return false
+		}
+		params[seg.paramName] = m.realInner(realSegments[j], inner, seg.prefix, seg.suffix)
+		if m.bindFrom(patternSegs, segments, realSegments, i+1, j+1, params, splatValues) {
+			return true
+		}
+		delete(params, seg.paramName)
+		return false
+
+	case segTypes.wildcard:
+		inner, ok := m.matchInner(segments[j], seg.prefix, seg.suffix, nil)
+		if !ok {
+			return false
+		}
+		*splatValues = append(*splatValues, m.realInner(realSegments[j], inner, seg.prefix, seg.suffix))
+		if m.bindFrom(patternSegs, segments, realSegments, i+1, j+1, params, splatValues) {
+			return true
+		}
+		*splatValues = (*splatValues)[:splatLen]
+		return false
+	}
+
+	return false
+}
@@ -0,0 +1,441 @@
+package matcher
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSegmentWildcardsAndAffixes(t *testing.T) {
+	m := New(&Options{Quiet: true})
+
+	tests := []struct {
+		pattern   string
+		wantTypes []segType
+		wantNorm  string
+	}{
+		{"/files/*/raw", []segType{"static", "wildcard", "static"}, "/files/*/raw"},
+		{"/files/*", []segType{"static", "splat"}, "/files/*"},
+		{"/assets/**/*.map", []segType{"static", "globstar", "wildcard"}, "/assets/**/*.map"},
+		{"/img/:name.png", []segType{"static", "dynamic"}, "/img/:name.png"},
+		{"/v:version/api", []segType{"dynamic", "static"}, "/v:version/api"},
+		{"/v:version<int>.json", []segType{"dynamic"}, "/v:version<int>.json"},
+		{"/img-*", []segType{"wildcard"}, "/img-*"},
+		{`/things\:batchGet`, []segType{"static"}, `/things\:batchGet`},
+		{`/file\*`, []segType{"static"}, `/file\*`},
+		{`/a\b`, []segType{"static"}, `/a\b`},
+		{`/v:version\:raw`, []segType{"dynamic"}, `/v:version\:raw`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			rp := m.NormalizePattern(tt.pattern)
+			var gotTypes []segType
+			for _, seg := range rp.normalizedSegments {
+				gotTypes = append(gotTypes, seg.segType)
+			}
+			if !reflect.DeepEqual(gotTypes, tt.wantTypes) {
+				t.Errorf("segment types = %v, want %v", gotTypes, tt.wantTypes)
+			}
+			if rp.normalizedPattern != tt.wantNorm {
+				t.Errorf("normalized pattern = %q, want %q", rp.normalizedPattern, tt.wantNorm)
+			}
+		})
+	}
+
+	rp := m.NormalizePattern("/v:version<int>.json")
+	seg := rp.normalizedSegments[0]
+	if seg.prefix != "v" || seg.paramName != "version" || seg.constraint.rawOrEmpty() != "int" || seg.suffix != ".json" {
+		t.Errorf("affix param parsed as prefix=%q name=%q constraint=%q suffix=%q", seg.prefix, seg.paramName, seg.constraint.rawOrEmpty(), seg.suffix)
+	}
+}
+
+func TestFindBestMatchWildcards(t *testing.T) {
+	tests := []struct {
+		name        string
+		patterns    []string
+		path        string
+		wantPattern string
+		wantParams  Params
+		wantSplat   []string
+	}{
+		{
+			name:        "mid-path wildcard",
+			patterns:    []string{"/files/*/raw"},
+			path:        "/files/abc/raw",
+			wantPattern: "/files/*/raw",
+			wantSplat:   []string{"abc"},
+		},
+		{
+			name:        "mid-path wildcard matches exactly one segment",
+			patterns:    []string{"/files/*/raw"},
+			path:        "/files/a/b/raw",
+			wantPattern: NOT_FOUND,
+		},
+		{
+			name:        "mid-path wildcard does not match an empty segment",
+			patterns:    []string{"/files/*/raw"},
+			path:        "/files//raw",
+			wantPattern: NOT_FOUND,
+		},
+		{
+			name:        "globstar with affix wildcard",
+			patterns:    []string{"/assets/**/*.map"},
+			path:        "/assets/js/vendor/app.js.map",
+			wantPattern: "/assets/**/*.map",
+			wantSplat:   []string{"js", "vendor", "app.js"},
+		},
+		{
+			name:        "globstar matches zero segments",
+			patterns:    []string{"/assets/**/*.map"},
+			path:        "/assets/app.map",
+			wantPattern: "/assets/**/*.map",
+			wantSplat:   []string{"app"},
+		},
+		{
+			name:        "trailing globstar matches zero segments",
+			patterns:    []string{"/docs/**"},
+			path:        "/docs",
+			wantPattern: "/docs/**",
+		},
+		{
+			name:        "affix wildcard requires a non-empty inner value",
+			patterns:    []string{"/assets/**/*.map"},
+			path:        "/assets/js/.map",
+			wantPattern: NOT_FOUND,
+		},
+		{
+			name:        "suffix param",
+			patterns:    []string{"/img/:name.png"},
+			path:        "/img/cat.png",
+			wantPattern: "/img/:name.png",
+			wantParams:  Params{"name": "cat"},
+		},
+		{
+			name:        "suffix param does not match other suffixes",
+			patterns:    []string{"/img/:name.png"},
+			path:        "/img/cat.jpg",
+			wantPattern: NOT_FOUND,
+		},
+		{
+			name:        "prefix param",
+			patterns:    []string{"/v:version/api"},
+			path:        "/v2/api",
+			wantPattern: "/v:version/api",
+			wantParams:  Params{"version": "2"},
+		},
+		{
+			name:        "affix param with constraint",
+			patterns:    []string{"/v:version<int>/api", "/:other/api"},
+			path:        "/vbeta/api",
+			wantPattern: "/:other/api",
+			wantParams:  Params{"other": "vbeta"},
+		},
+		{
+			name:        "affix param beats bare param",
+			patterns:    []string{"/img/:file", "/img/:name.png"},
+			path:        "/img/cat.png",
+			wantPattern: "/img/:name.png",
+			wantParams:  Params{"name": "cat"},
+		},
+		{
+			name:        "static beats affix param",
+			patterns:    []string{"/img/:name.png", "/img/logo.png"},
+			path:        "/img/logo.png",
+			wantPattern: "/img/logo.png",
+		},
+		{
+			name:        "affix param beats constrained param",
+			patterns:    []string{"/v:version/api", "/:version<[a-z0-9]+>/api"},
+			path:        "/v2/api",
+			wantPattern: "/v:version/api",
+			wantParams:  Params{"version": "2"},
+		},
+		{
+			name:        "static beats mid-path wildcard",
+			patterns:    []string{"/files/*/raw", "/files/readme/raw"},
+			path:        "/files/readme/raw",
+			wantPattern: "/files/readme/raw",
+		},
+		{
+			name:        "mid-path wildcard beats trailing splat",
+			patterns:    []string{"/files/*", "/files/*/raw"},
+			path:        "/files/abc/raw",
+			wantPattern: "/files/*/raw",
+			wantSplat:   []string{"abc"},
+		},
+		{
+			name:        "params and wildcards together",
+			patterns:    []string{"/users/:id/**/:file.txt"},
+			path:        "/users/42/a/b/notes.txt",
+			wantPattern: "/users/:id/**/:file.txt",
+			wantParams:  Params{"id": "42", "file": "notes"},
+			wantSplat:   []string{"a", "b"},
+		},
+		{
+			name:        "wildcard with trailing slash",
+			patterns:    []string{"/files/*/raw"},
+			path:        "/files/abc/raw/",
+			wantPattern: "/files/*/raw",
+			wantSplat:   []string{"abc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(&Options{Quiet: true})
+			for _, p := range tt.patterns {
+				m.RegisterPattern(p)
+			}
+
+			match, ok := m.FindBestMatch(tt.path)
+
+			if tt.wantPattern == NOT_FOUND {
+				if ok {
+					t.Errorf("FindBestMatch(%q) = %q, want no match", tt.path, match.normalizedPattern)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("FindBestMatch(%q) found no match, want %q", tt.path, tt.wantPattern)
+			}
+			if match.normalizedPattern != tt.wantPattern {
+				t.Errorf("FindBestMatch(%q) pattern = %q, want %q", tt.path, match.normalizedPattern, tt.wantPattern)
+			}
+			if !equalParams(match.Params, tt.wantParams) {
+				t.Errorf("FindBestMatch(%q) params = %v, want %v", tt.path, match.Params, tt.wantParams)
+			}
+			if !equalSplat(match.SplatValues, tt.wantSplat) {
+				t.Errorf("FindBestMatch(%q) splat = %v, want %v", tt.path, match.SplatValues, tt.wantSplat)
+			}
+		})
+	}
+}
+
+func TestFindBestMatchWildcardsWithNormalizationModes(t *testing.T) {
+	m := New(&Options{Quiet: true, CaseInsensitive: true, DecodePercentEncoding: true})
+	m.RegisterPattern("/img/:name.png")
+	m.RegisterPattern("/files/*/raw")
+
+	match, ok := m.FindBestMatch("/IMG/My%20Cat.PNG")
+	if !ok {
+		t.Fatal("FindBestMatch found no match for affix param")
+	}
+	if want := (Params{"name": "My%20Cat"}); !equalParams(match.Params, want) {
+		t.Errorf("params = %v, want %v", match.Params, want)
+	}
+
+	match, ok = m.FindBestMatch("/FILES/a%20b/RAW")
+	if !ok {
+		t.Fatal("FindBestMatch found no match for wildcard")
+	}
+	if want := []string{"a%20b"}; !equalSplat(match.SplatValues, want) {
+		t.Errorf("splat = %v, want %v", match.SplatValues, want)
+	}
+}
+
+func TestFindNestedMatchesWildcards(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	m.RegisterPattern("/repos/:owner")
+	m.RegisterPattern("/repos/:owner/*/settings")
+	m.RegisterPattern("/assets/**/*.map")
+	m.RegisterPattern("/img/:name.png")
+
+	tests := []struct {
+		path         string
+		wantPatterns []string
+		wantParams   Params
+		wantSplat    []string
+	}{
+		{
+			path:         "/repos/sjc5/kit/settings",
+			wantPatterns: []string{"/repos/:owner", "/repos/:owner/*/settings"},
+			wantParams:   Params{"owner": "sjc5"},
+			wantSplat:    []string{"kit"},
+		},
+		{
+			path:         "/assets/js/app.map",
+			wantPatterns: []string{"/assets/**/*.map"},
+			wantSplat:    []string{"js", "app"},
+		},
+		{
+			path:         "/img/cat.png",
+			wantPatterns: []string{"/img/:name.png"},
+			wantParams:   Params{"name": "cat"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			results, ok := m.FindNestedMatches(tt.path)
+			if !ok {
+				t.Fatalf("FindNestedMatches(%q) found no matches", tt.path)
+			}
+			var got []string
+			for _, match := range results.Matches {
+				got = append(got, match.normalizedPattern)
+			}
+			if !reflect.DeepEqual(got, tt.wantPatterns) {
+				t.Errorf("patterns = %v, want %v", got, tt.wantPatterns)
+			}
+			if !equalParams(results.Params, tt.wantParams) {
+				t.Errorf("params = %v, want %v", results.Params, tt.wantParams)
+			}
+			if !equalSplat(results.SplatValues, tt.wantSplat) {
+				t.Errorf("splat = %v, want %v", results.SplatValues, tt.wantSplat)
+			}
+		})
+	}
+
+	if _, ok := m.FindNestedMatches("/img/cat.jpg"); ok {
+		t.Error("FindNestedMatches(/img/cat.jpg) should not match /img/:name.png")
+	}
+}
+
+func TestBuildPathWildcards(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	m.RegisterPattern("/files/*/raw")
+	m.RegisterPattern("/assets/**/*.map")
+	m.RegisterPattern("/img/:name.png")
+	m.RegisterPattern("/v:version/api")
+
+	tests := []struct {
+		name    string
+		pattern string
+		params  Params
+		splat   []string
+		want    string
+		wantErr bool
+	}{
+		{name: "wildcard", pattern: "/files/*/raw", splat: []string{"a b"}, want: "/files/a%20b/raw"},
+		{name: "globstar and affix wildcard", pattern: "/assets/**/*.map", splat: []string{"js", "vendor", "app"}, want: "/assets/js/vendor/app.map"},
+		{name: "empty globstar", pattern: "/assets/**/*.map", splat: []string{"app"}, want: "/assets/app.map"},
+		{name: "suffix param", pattern: "/img/:name.png", params: Params{"name": "cat"}, want: "/img/cat.png"},
+		{name: "prefix param", pattern: "/v:version/api", params: Params{"version": "2"}, want: "/v2/api"},
+
+		{name: "missing wildcard value", pattern: "/files/*/raw", wantErr: true},
+		{name: "too many wildcard values", pattern: "/files/*/raw", splat: []string{"a", "b"}, wantErr: true},
+		{name: "missing affix wildcard value", pattern: "/assets/**/*.map", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.BuildPath(tt.pattern, tt.params, tt.splat)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("BuildPath() = %q, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BuildPath() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("BuildPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrictModeAffixSiblings(t *testing.T) {
+	m := New(&Options{Quiet: true, Strict: true})
+	m.RegisterPattern("/img/:name.png")
+
+	// Different suffixes never compete for the same segments
+	if _, err := m.TryRegisterPattern("/img/:file.jpg"); err != nil {
+		t.Errorf("TryRegisterPattern(/img/:file.jpg) error = %v", err)
+	}
+	if _, err := m.TryRegisterPattern("/img/:file.png"); err == nil {
+		t.Error("TryRegisterPattern(/img/:file.png) should conflict with /img/:name.png")
+	}
+}
+
+func TestUnregisterWildcardPattern(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	m.RegisterPattern("/assets/**/*.map")
+	m.RegisterPattern("/files/*/raw")
+
+	if !m.UnregisterPattern("/assets/**/*.map") {
+		t.Fatal("UnregisterPattern(/assets/**/*.map) = false, want true")
+	}
+	if _, ok := m.FindBestMatch("/assets/a/b.map"); ok {
+		t.Error("FindBestMatch still matches after unregistering")
+	}
+	if _, ok := m.FindBestMatch("/files/a/raw"); !ok {
+		t.Error("FindBestMatch(/files/a/raw) no longer matches")
+	}
+}
+
+func TestEscapedSegments(t *testing.T) {
+	m := New(&Options{Quiet: true})
+	m.RegisterPattern(`/things\:batchGet`)
+	m.RegisterPattern("/things/:id")
+	m.RegisterPattern(`/file\*`)
+	m.RegisterPattern(`/v:version\:raw`)
+	cm := m.Compile()
+
+	tests := []struct {
+		path        string
+		wantPattern string
+		wantParams  Params
+	}{
+		{"/things:batchGet", `/things\:batchGet`, nil},
+		{"/thingsXbatchGet", NOT_FOUND, nil},
+		{"/file*", `/file\*`, nil},
+		{"/file.txt", NOT_FOUND, nil},
+		{"/v2:raw", `/v:version\:raw`, Params{"version": "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			match, ok := m.FindBestMatch(tt.path)
+			compiled, compiledOK := cm.FindBestMatch(tt.path)
+			if tt.wantPattern == NOT_FOUND {
+				if ok || compiledOK {
+					t.Errorf("FindBestMatch(%q) matched, want no match", tt.path)
+				}
+				return
+			}
+			if !ok || match.normalizedPattern != tt.wantPattern {
+				t.Fatalf("FindBestMatch(%q) = %v, %v, want %q", tt.path, match, ok, tt.wantPattern)
+			}
+			if !compiledOK || compiled.normalizedPattern != tt.wantPattern {
+				t.Errorf("compiled FindBestMatch(%q) = %v, %v, want %q", tt.path, compiled, compiledOK, tt.wantPattern)
+			}
+			if !equalParams(match.Params, tt.wantParams) {
+				t.Errorf("FindBestMatch(%q) params = %v, want %v", tt.path, match.Params, tt.wantParams)
+			}
+		})
+	}
+
+	results, ok := m.FindNestedMatches("/things:batchGet")
+	if !ok || len(results.Matches) != 1 || results.Matches[0].normalizedPattern != `/things\:batchGet` {
+		t.Errorf("FindNestedMatches(/things:batchGet) = %v, %v, want the escaped pattern", results, ok)
+	}
+
+	if got, err := m.BuildPath(`/things\:batchGet`, nil, nil); err != nil || got != "/things:batchGet" {
+		t.Errorf("BuildPath = %q, %v, want /things:batchGet", got, err)
+	}
+	if got, err := m.BuildPath(`/v:version\:raw`, Params{"version": "2"}, nil); err != nil || got != "/v2:raw" {
+		t.Errorf("BuildPath = %q, %v, want /v2:raw", got, err)
+	}
+
+	if !m.UnregisterPattern(`/things\:batchGet`) {
+		t.Fatal("UnregisterPattern(/things\\:batchGet) = false, want true")
+	}
+	if _, ok := m.FindBestMatch("/things:batchGet"); ok {
+		t.Error("FindBestMatch still matches after unregistering")
+	}
+}
+
+func TestEmptyParamNamePanics(t *testing.T) {
+	for _, pattern := range []string{"/v:", "/users/:", "/a:.json", "/:<int>"} {
+		t.Run(pattern, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterPattern(%q) did not panic", pattern)
+				}
+			}()
+			New(&Options{Quiet: true}).RegisterPattern(pattern)
+		})
+	}
+}
@@ -0,0 +1,321 @@
+package router
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/sjc5/kit/pkg/contextutil"
+	"github.com/sjc5/kit/pkg/matcher"
+)
+
+type Middleware = func(http.Handler) http.Handler
+
+type Options struct {
+	MatcherOptions *matcher.Options // Optional. Passed through to matcher.New.
+
+	NotFoundHandler http.Handler // Optional. Defaults to http.NotFound.
+
+	// Optional. Defaults to a plain 405 response. The Allow header is
+	// already set by the time this handler is called.
+	MethodNotAllowedHandler http.Handler
+}
+
+type Router struct {
+	matcher     *matcher.Matcher
+	routes      map[string]*route // original pattern -> route
+	middlewares []Middleware
+
+	notFoundHandler         http.Handler
+	methodNotAllowedHandler http.Handler
+}
+
+type route struct {
+	handlers map[string]http.Handler // method -> handler, already wrapped with group middlewares
+}
+
+var matchStore = contextutil.NewStore[*matcher.Match]("router-match")
+
+func New(opts *Options) *Router {
+	if opts == nil {
+		opts = new(Options)
+	}
+
+	rt := &Router{
+		matcher:                 matcher.New(opts.MatcherOptions),
+		routes:                  make(map[string]*route),
+		notFoundHandler:         opts.NotFoundHandler,
+		methodNotAllowedHandler: opts.MethodNotAllowedHandler,
+	}
+
+	if rt.notFoundHandler == nil {
+		rt.notFoundHandler = http.HandlerFunc(http.NotFound)
+	}
+	if rt.methodNotAllowedHandler == nil {
+		rt.methodNotAllowedHandler = http.HandlerFunc(defaultMethodNotAllowed)
+	}
+
+	return rt
+}
+
+// Matcher returns the underlying matcher, e.g. for building paths or
+// inspecting the route table.
+func (rt *Router) Matcher() *matcher.Matcher {
+	return rt.matcher
+}
+
+// Use adds middlewares that wrap every request handled by the router,
+// including not found, method not allowed, and automatic OPTIONS responses.
+func (rt *Router) Use(middlewares ...Middleware) {
+	rt.middlewares = append(rt.middlewares, middlewares...)
+}
+
+func (rt *Router) Handle(method, pattern string, handler http.Handler) {
+	rt.handle(method, pattern, handler)
+}
+
+func (rt *Router) HandleFunc(method, pattern string, handlerFunc http.HandlerFunc) {
+	rt.handle(method, pattern, handlerFunc)
+}
+
+// Group returns a group whose patterns are all prefixed with the given prefix
+// and whose handlers are all wrapped with the given middlewares. A
+// host-qualified prefix (e.g. "//:tenant.example.com/app") qualifies every
+// pattern in the group with its host.
+func (rt *Router) Group(prefix string, middlewares ...Middleware) *Group {
+	host, path, ok := matcher.SplitHostPattern(prefix)
+	if !ok {
+		path = prefix
+	}
+	return &Group{
+		router:      rt,
+		host:        host,
+		base:        rt.matcher.NormalizePattern(path),
+		middlewares: middlewares,
+	}
+}
+
+func (rt *Router) handle(method, pattern string, handler http.Handler) {
+	method = strings.ToUpper(method)
+
+	r, ok := rt.routes[pattern]
+	if !ok {
+		rt.matcher.RegisterPattern(pattern)
+		r = &route{handlers: make(map[string]http.Handler)}
+		rt.routes[pattern] = r
+	}
+
+	if _, exists := r.handlers[method]; exists {
+		panic("router: handler already registered for " + method + " " + pattern)
+	}
+
+	r.handlers[method] = handler
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var handler http.Handler = http.HandlerFunc(rt.serve)
+	for i := len(rt.middlewares) - 1; i >= 0; i-- {
+		handler = rt.middlewares[i](handler)
+	}
+	handler.ServeHTTP(w, r)
+}
+
+func (rt *Router) serve(w http.ResponseWriter, r *http.Request) {
+	var match *matcher.Match
+	var ok bool
+
+	if rt.matcher.TrailingSlashPolicy() == matcher.TrailingSlashRedirect {
+		var canonical string
+		match, canonical, ok = rt.findCanonicalMatch(r)
+		if ok && canonical != r.URL.Path {
+			redirectToCanonical(w, r, canonical)
+			return
+		}
+	} else {
+		match, ok = rt.matcher.FindBestMatchForHost(r.Host, r.URL.Path)
+	}
+
+	if !ok {
+		rt.notFoundHandler.ServeHTTP(w, r)
+		return
+	}
+
+	route := rt.routes[match.OriginalPattern()]
+	if route == nil {
+		rt.notFoundHandler.ServeHTTP(w, r)
+		return
+	}
+
+	r = matchStore.GetRequestWithContext(r, match)
+
+	if handler, ok := route.handlers[r.Method]; ok {
+		handler.ServeHTTP(w, r)
+		return
+	}
+
+	if r.Method == http.MethodHead {
+		if handler, ok := route.handlers[http.MethodGet]; ok {
+			handler.ServeHTTP(w, r)
+			return
+		}
+	}
+
+	w.Header().Set("Allow", route.allowHeader())
+
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	rt.methodNotAllowedHandler.ServeHTTP(w, r)
+}
+
+// findCanonicalMatch finds the match for the request, along with the
+// canonical path for it. Duplicate slashes, "." and ".." segments, and
+// trailing slashes that disagree with the matched pattern are all corrected.
+func (rt *Router) findCanonicalMatch(r *http.Request) (*matcher.Match, string, bool) {
+	cleaned := matcher.CleanPath(r.URL.Path)
+
+	match, ok := rt.matcher.FindBestMatchForHost(r.Host, cleaned)
+	if !ok && !strings.HasSuffix(cleaned, "/") {
+		// The canonical form may be an index route
+		match, ok = rt.matcher.FindBestMatchForHost(r.Host, cleaned+"/")
+		if ok && !match.LastSegIsIndex() {
+			ok = false
+		}
+	}
+	if !ok {
+		return nil, "", false
+	}
+
+	return match, matcher.CanonicalPath(match, cleaned), true
+}
+
+// redirectToCanonical redirects permanently, with 301 for GET and HEAD
+// requests and 308 for everything else (so that the method and body are
+// preserved).
+func redirectToCanonical(w http.ResponseWriter, r *http.Request, canonical string) {
+	code := http.StatusPermanentRedirect
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		code = http.StatusMovedPermanently
+	}
+
+	u := url.URL{Path: canonical, RawQuery: r.URL.RawQuery}
+	http.Redirect(w, r, u.RequestURI(), code)
+}
+
+func (r *route) allowHeader() string {
+	methods := make([]string, 0, len(r.handlers)+2)
+	for method := range r.handlers {
+		methods = append(methods, method)
+	}
+	if _, ok := r.handlers[http.MethodGet]; ok && !slices.Contains(methods, http.MethodHead) {
+		methods = append(methods, http.MethodHead)
+	}
+	if !slices.Contains(methods, http.MethodOptions) {
+		methods = append(methods, http.MethodOptions)
+	}
+	slices.Sort(methods)
+	return strings.Join(methods, ", ")
+}
+
+func defaultMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
+
+/////////////////////////////////////////////////////////////////////
+/////// GROUPS
+/////////////////////////////////////////////////////////////////////
+
+type Group struct {
+	router      *Router
+	host        string // host part of a host-qualified prefix, if any
+	base        *matcher.RegisteredPattern
+	middlewares []Middleware
+}
+
+// Group returns a nested group, inheriting this group's prefix (including any
+// host) and middlewares.
+func (g *Group) Group(prefix string, middlewares ...Middleware) *Group {
+	host, path := g.splitHost(prefix)
+	return &Group{
+		router:      g.router,
+		host:        host,
+		base:        g.router.matcher.NormalizePattern(g.joinPath(path)),
+		middlewares: append(slices.Clone(g.middlewares), middlewares...),
+	}
+}
+
+func (g *Group) Handle(method, pattern string, handler http.Handler) {
+	for i := len(g.middlewares) - 1; i >= 0; i-- {
+		handler = g.middlewares[i](handler)
+	}
+	g.router.handle(method, g.join(pattern), handler)
+}
+
+func (g *Group) HandleFunc(method, pattern string, handlerFunc http.HandlerFunc) {
+	g.Handle(method, pattern, handlerFunc)
+}
+
+// join prefixes a pattern with the group's host and base pattern. An empty
+// pattern refers to the base pattern itself.
+func (g *Group) join(pattern string) string {
+	host, path := g.splitHost(pattern)
+	if host == "" {
+		return g.joinPath(path)
+	}
+	return "//" + host + g.joinPath(path)
+}
+
+func (g *Group) joinPath(path string) string {
+	if path == "" {
+		return g.base.NormalizedPattern()
+	}
+	return matcher.JoinPatterns(g.base, path)
+}
+
+// splitHost splits a pattern given to the group into its host and path parts.
+// The host defaults to the group's host; a group can't be qualified with two
+// hosts. A host-only pattern refers to the base pattern itself.
+func (g *Group) splitHost(pattern string) (host, path string) {
+	host, path, ok := matcher.SplitHostPattern(pattern)
+	if !ok {
+		return g.host, pattern
+	}
+	if g.host != "" {
+		panic("router: pattern '" + pattern + "' is host-qualified, but its group already has host '" + g.host + "'")
+	}
+	if pattern == "//"+host {
+		path = ""
+	}
+	return host, path
+}
+
+/////////////////////////////////////////////////////////////////////
+/////// REQUEST HELPERS
+/////////////////////////////////////////////////////////////////////
+
+// GetMatch returns the match for the current request, or nil if the
+// request was not routed by a Router.
+func GetMatch(r *http.Request) *matcher.Match {
+	return matchStore.GetValueFromContext(r)
+}
+
+func GetParams(r *http.Request) matcher.Params {
+	if match := GetMatch(r); match != nil {
+		return match.Params
+	}
+	return nil
+}
+
+func GetParam(r *http.Request, name string) string {
+	return GetParams(r)[name]
+}
+
+func GetSplatValues(r *http.Request) []string {
+	if match := GetMatch(r); match != nil {
+		return match.SplatValues
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package router
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sjc5/kit/pkg/matcher"
+)
+
+func textHandler(text string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, text)
+	}
+}
+
+func headerMiddleware(key, value string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add(key, value)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func serve(rt *Router, method, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	rt.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+func TestRouterMethodDispatch(t *testing.T) {
+	rt := New(&Options{MatcherOptions: &matcher.Options{Quiet: true}})
+	rt.HandleFunc(http.MethodGet, "/users", textHandler("list"))
+	rt.HandleFunc(http.MethodPost, "/users", textHandler("create"))
+	rt.HandleFunc("delete", "/users/:id", textHandler("delete"))
+
+	tests := []struct {
+		method     string
+		path       string
+		wantStatus int
+		wantBody   string
+		wantAllow  string
+	}{
+		{http.MethodGet, "/users", http.StatusOK, "list", ""},
+		{http.MethodPost, "/users", http.StatusOK, "create", ""},
+		{http.MethodDelete, "/users/1", http.StatusOK, "delete", ""},
+		{http.MethodPut, "/users", http.StatusMethodNotAllowed, "", "GET, HEAD, OPTIONS, POST"},
+		{http.MethodGet, "/users/1", http.StatusMethodNotAllowed, "", "DELETE, OPTIONS"},
+		{http.MethodHead, "/users", http.StatusOK, "list", ""},
+		{http.MethodHead, "/users/1", http.StatusMethodNotAllowed, "", "DELETE, OPTIONS"},
+		{http.MethodOptions, "/users", http.StatusNoContent, "", "GET, HEAD, OPTIONS, POST"},
+		{http.MethodGet, "/nope", http.StatusNotFound, "", ""},
+	}
+
+	for _, tt := range tests {
+		rec := serve(rt, tt.method, tt.path)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s %s status = %d, want %d", tt.method, tt.path, rec.Code, tt.wantStatus)
+		}
+		if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+			t.Errorf("%s %s body = %q, want %q", tt.method, tt.path, rec.Body.String(), tt.wantBody)
+		}
+		if got := rec.Header().Get("Allow"); got != tt.wantAllow {
+			t.Errorf("%s %s Allow = %q, want %q", tt.method, tt.path, got, tt.wantAllow)
+		}
+	}
+}
+
+func TestRouterParamsInContext(t *testing.T) {
+	rt := New(nil)
+
+	var gotParams matcher.Params
+	var gotSplat []string
+	var gotParam string
+
+	rt.HandleFunc(http.MethodGet, "/users/:id/posts/:post_id", func(w http.ResponseWriter, r *http.Request) {
+		gotParams = GetParams(r)
+		gotParam = GetParam(r, "id")
+	})
+	rt.HandleFunc(http.MethodGet, "/files/*", func(w http.ResponseWriter, r *http.Request) {
+		gotSplat = GetSplatValues(r)
+	})
+
+	serve(rt, http.MethodGet, "/users/42/posts/7")
+	if want := (matcher.Params{"id": "42", "post_id": "7"}); !reflect.DeepEqual(gotParams, want) {
+		t.Errorf("GetParams() = %v, want %v", gotParams, want)
+	}
+	if gotParam != "42" {
+		t.Errorf("GetParam() = %q, want %q", gotParam, "42")
+	}
+
+	serve(rt, http.MethodGet, "/files/a/b.txt")
+	if want := []string{"a", "b.txt"}; !reflect.DeepEqual(gotSplat, want) {
+		t.Errorf("GetSplatValues() = %v, want %v", gotSplat, want)
+	}
+
+	if GetMatch(httptest.NewRequest(http.MethodGet, "/", nil)) != nil {
+		t.Error("GetMatch() on unrouted request should be nil")
+	}
+}
+
+func TestRouterHostPatterns(t *testing.T) {
+	rt := New(&Options{MatcherOptions: &matcher.Options{Quiet: true}})
+
+	var gotTenant string
+	rt.HandleFunc(http.MethodGet, "//:tenant.example.com/dashboard", func(w http.ResponseWriter, r *http.Request) {
+		gotTenant = GetParam(r, "tenant")
+		io.WriteString(w, "tenant")
+	})
+	rt.HandleFunc(http.MethodGet, "/dashboard", textHandler("fallback"))
+
+	req := httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+	req.Host = "acme.example.com:3000"
+	rec := httptest.NewRecorder()
+	rt.ServeHTTP(rec, req)
+	if rec.Body.String() != "tenant" || gotTenant != "acme" {
+		t.Errorf("tenant host: body = %q, tenant = %q", rec.Body.String(), gotTenant)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/dashboard", nil)
+	req.Host = "example.org"
+	rec = httptest.NewRecorder()
+	rt.ServeHTTP(rec, req)
+	if rec.Body.String() != "fallback" {
+		t.Errorf("other host: body = %q, want %q", rec.Body.String(), "fallback")
+	}
+}
+
+func TestRouterCanonicalRedirects(t *testing.T) {
+	rt := New(&Options{MatcherOptions: &matcher.Options{Quiet: true, TrailingSlash: matcher.TrailingSlashRedirect}})
+	rt.HandleFunc(http.MethodGet, "/about", textHandler("about"))
+	rt.HandleFunc(http.MethodPost, "/users/:id", textHandler("user"))
+	rt.HandleFunc(http.MethodGet, "/docs/", textHandler("docs"))
+
+	tests := []struct {
+		method       string
+		path         string
+		wantCode     int
+		wantLocation string
+	}{
+		{http.MethodGet, "/about", http.StatusOK, ""},
+		{http.MethodGet, "/about/", http.StatusMovedPermanently, "/about"},
+		{http.MethodGet, "//about?x=1", http.StatusMovedPermanently, "/about?x=1"},
+		{http.MethodGet, "/docs/../about", http.StatusMovedPermanently, "/about"},
+		{http.MethodGet, "/docs", http.StatusMovedPermanently, "/docs/"},
+		{http.MethodPost, "/users/42/", http.StatusPermanentRedirect, "/users/42"},
+		{http.MethodPost, "/users/./42", http.StatusPermanentRedirect, "/users/42"},
+		{http.MethodGet, "/nope/", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := serve(rt, tt.method, tt.path)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if got := rec.Header().Get("Location"); got != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", got, tt.wantLocation)
+			}
+		})
+	}
+}
+
+func TestRouterGroups(t *testing.T) {
+	rt := New(nil)
+	rt.Use(headerMiddleware("X-Trace", "router"))
+
+	api := rt.Group("/api", headerMiddleware("X-Trace", "api"))
+	api.HandleFunc(http.MethodGet, "", textHandler("api root"))
+	api.HandleFunc(http.MethodGet, "/health", textHandler("ok"))
+
+	v1 := api.Group("/v1", headerMiddleware("X-Trace", "v1"))
+	v1.HandleFunc(http.MethodGet, "/users/:id", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "user "+GetParam(r, "id"))
+	})
+
+	tests := []struct {
+		path      string
+		wantBody  string
+		wantTrace []string
+	}{
+		{"/api", "api root", []string{"router", "api"}},
+		{"/api/health", "ok", []string{"router", "api"}},
+		{"/api/v1/users/9", "user 9", []string{"router", "api", "v1"}},
+		{"/nope", "404 page not found\n", []string{"router"}},
+	}
+
+	for _, tt := range tests {
+		rec := serve(rt, http.MethodGet, tt.path)
+		if rec.Body.String() != tt.wantBody {
+			t.Errorf("GET %s body = %q, want %q", tt.path, rec.Body.String(), tt.wantBody)
+		}
+		if got := rec.Header().Values("X-Trace"); !reflect.DeepEqual(got, tt.wantTrace) {
+			t.Errorf("GET %s X-Trace = %v, want %v", tt.path, got, tt.wantTrace)
+		}
+	}
+}
+
+func TestRouterHostGroups(t *testing.T) {
+	rt := New(&Options{MatcherOptions: &matcher.Options{Quiet: true}})
+
+	tenant := rt.Group("//:tenant.example.com/app")
+	tenant.HandleFunc(http.MethodGet, "", textHandler("tenant root"))
+	tenant.Group("/admin").HandleFunc(http.MethodGet, "/users", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "users of "+GetParam(r, "tenant"))
+	})
+
+	api := rt.Group("/api")
+	api.HandleFunc(http.MethodGet, "//admin.example.com", textHandler("admin api"))
+	api.HandleFunc(http.MethodGet, "", textHandler("api"))
+
+	tests := []struct {
+		host     string
+		path     string
+		wantBody string
+	}{
+		{"acme.example.com", "/app", "tenant root"},
+		{"acme.example.com", "/app/admin/users", "users of acme"},
+		{"example.org", "/app/admin/users", "404 page not found\n"},
+		{"admin.example.com", "/api", "admin api"},
+		{"example.org", "/api", "api"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.Host = tt.host
+		rec := httptest.NewRecorder()
+		rt.ServeHTTP(rec, req)
+		if rec.Body.String() != tt.wantBody {
+			t.Errorf("GET %s%s body = %q, want %q", tt.host, tt.path, rec.Body.String(), tt.wantBody)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("a second host in a host-qualified group should panic")
+		}
+	}()
+	tenant.HandleFunc(http.MethodGet, "//other.example.com/x", textHandler("x"))
+}
+
+func TestRouterCustomHandlers(t *testing.T) {
+	rt := New(&Options{
+		NotFoundHandler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		}),
+		MethodNotAllowedHandler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "allowed: "+w.Header().Get("Allow"))
+		}),
+	})
+	rt.HandleFunc(http.MethodPost, "/items", textHandler("created"))
+
+	if rec := serve(rt, http.MethodGet, "/nope"); rec.Code != http.StatusTeapot {
+		t.Errorf("not found status = %d, want %d", rec.Code, http.StatusTeapot)
+	}
+	if rec := serve(rt, http.MethodGet, "/items"); !strings.Contains(rec.Body.String(), "POST") {
+		t.Errorf("method not allowed body = %q, want it to list POST", rec.Body.String())
+	}
+}
+
+func TestRouterExplicitOptionsHandlerWins(t *testing.T) {
+	rt := New(nil)
+	rt.HandleFunc(http.MethodGet, "/x", textHandler("get"))
+	rt.HandleFunc(http.MethodOptions, "/x", textHandler("custom options"))
+
+	rec := serve(rt, http.MethodOptions, "/x")
+	if rec.Body.String() != "custom options" {
+		t.Errorf("OPTIONS body = %q, want %q", rec.Body.String(), "custom options")
+	}
+}
+
+func TestRouterDuplicateHandlerPanics(t *testing.T) {
+	rt := New(nil)
+	rt.HandleFunc(http.MethodGet, "/x", textHandler("a"))
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic on duplicate method and pattern")
+		}
+	}()
+	rt.HandleFunc(http.MethodGet, "/x", textHandler("b"))
+}
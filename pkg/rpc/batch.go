@@ -0,0 +1,124 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/sjc5/kit/pkg/response"
+)
+
+// BatchKey is the reserved key at which the batch endpoint is served (unless
+// ServerOpts.DisableBatch is set). A batch request is a POST whose JSON body
+// is an array of {"key": ..., "input": ...} items. The response is an array
+// of results in the same order, each either {"data": ...} or
+// {"error": ..., "status": ...}, where status is the HTTP status the error
+// would have had on its own.
+//
+// Queries and mutations can both be batched. Unlike a standalone query, a
+// batched query's input is decoded from JSON. Subscriptions can't be batched.
+// Items normally run concurrently, but a batch that contains a mutation runs
+// its items one at a time, in order, so that later items see the effects of
+// earlier ones.
+const BatchKey = "_batch"
+
+const (
+	defaultBatchConcurrency = 8
+	defaultMaxBatchSize     = 32
+)
+
+type batchItem struct {
+	Key   string          `json:"key"`
+	Input json.RawMessage `json:"input"`
+}
+
+type batchResult struct {
+	Data   json.RawMessage `json:"data,omitempty"`
+	Error  *Error          `json:"error,omitempty"`
+	Status int             `json:"status,omitempty"`
+}
+
+func (s *Server) serveBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, NewError(ErrorCodeMethodNotAllowed, "batch requests require "+http.MethodPost))
+		return
+	}
+
+	var items []batchItem
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		writeError(w, NewError(ErrorCodeBadRequest, fmt.Sprintf("error decoding batch: %s", err)))
+		return
+	}
+	if len(items) == 0 {
+		writeError(w, NewError(ErrorCodeBadRequest, "batch must not be empty"))
+		return
+	}
+	if len(items) > s.maxBatchSize {
+		writeError(w, NewError(ErrorCodeBadRequest, "batch must not have more than "+strconv.Itoa(s.maxBatchSize)+" items"))
+		return
+	}
+
+	results := make([]batchResult, len(items))
+
+	concurrency := s.batchConcurrency
+	if slices.ContainsFunc(items, s.isMutation) {
+		concurrency = 1
+	}
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for range min(concurrency, len(items)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				results[i] = s.runBatchItem(r, items[i])
+			}
+		}()
+	}
+	for i := range items {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	res := response.New(w)
+	res.JSON(results)
+}
+
+func (s *Server) runBatchItem(r *http.Request, item batchItem) (result batchResult) {
+	rt := s.routes[item.Key]
+	if rt == nil {
+		return errorResult(NewError(ErrorCodeNotFound, "no route for key "+item.Key))
+	}
+	if rt.callJSON == nil {
+		return errorResult(NewError(ErrorCodeBadRequest, "route "+item.Key+" can't be batched"))
+	}
+
+	// A panic would otherwise take down the whole process, since handlers
+	// aren't running on the request's goroutine
+	defer func() {
+		if p := recover(); p != nil {
+			result = errorResult(s.handlerError(r, item.Key, fmt.Errorf("panic: %v", p)))
+		}
+	}()
+
+	data, rpcErr := rt.callJSON(s, r, item.Input)
+	if rpcErr != nil {
+		return errorResult(rpcErr)
+	}
+	return batchResult{Data: data}
+}
+
+func (s *Server) isMutation(item batchItem) bool {
+	rt := s.routes[item.Key]
+	return rt != nil && rt.def.ActionType == ActionTypeMutation
+}
+
+func errorResult(e *Error) batchResult {
+	return batchResult{Error: e, Status: e.status()}
+}
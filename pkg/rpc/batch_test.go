@@ -0,0 +1,261 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type batchResultForTest struct {
+	Data   json.RawMessage `json:"data"`
+	Error  *Error          `json:"error"`
+	Status int             `json:"status"`
+}
+
+func doBatch(t *testing.T, s http.Handler, body string) []batchResultForTest {
+	t.Helper()
+	rec := do(s, http.MethodPost, "/api/"+BatchKey, body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body: %s)", rec.Code, rec.Body)
+	}
+	var results []batchResultForTest
+	if err := json.Unmarshal(rec.Body.Bytes(), &results); err != nil {
+		t.Fatalf("failed to decode batch results: %v", err)
+	}
+	return results
+}
+
+func TestBatch(t *testing.T) {
+	s := newTestServer(nil)
+	s.Register(
+		Query("boom", func(ctx context.Context, input struct{}) (int, error) {
+			panic("boom")
+		}),
+		Subscription("ticks", func(ctx context.Context, input struct{}, stream *Stream[int]) error {
+			return nil
+		}),
+	)
+
+	results := doBatch(t, s, `[
+		{"key": "greet", "input": {"name": "Bob", "times": 1}},
+		{"key": "rename", "input": {"id": 1, "name": "x"}},
+		{"key": "rename", "input": {"id": 1}},
+		{"key": "nope"},
+		{"key": "ticks"},
+		{"key": "boom"},
+		{"key": "ping"}
+	]`)
+
+	if len(results) != 7 {
+		t.Fatalf("got %d results, want 7", len(results))
+	}
+
+	wantData := map[int]string{0: `{"greeting":"hi Bob "}`, 1: `true`, 6: `"pong"`}
+	for i, want := range wantData {
+		if results[i].Error != nil || string(results[i].Data) != want {
+			t.Errorf("results[%d] = {data: %s, error: %v}, want data %s", i, results[i].Data, results[i].Error, want)
+		}
+	}
+
+	wantErrors := map[int]struct {
+		code   ErrorCode
+		status int
+	}{
+		2: {ErrorCodeValidation, http.StatusBadRequest},
+		3: {ErrorCodeNotFound, http.StatusNotFound},
+		4: {ErrorCodeBadRequest, http.StatusBadRequest},
+		5: {ErrorCodeInternal, http.StatusInternalServerError},
+	}
+	for i, want := range wantErrors {
+		if results[i].Error == nil || results[i].Error.Code != want.code || results[i].Status != want.status {
+			t.Errorf("results[%d] = {error: %v, status: %d}, want %s with %d", i, results[i].Error, results[i].Status, want.code, want.status)
+		}
+	}
+}
+
+func TestBatchConcurrencyIsBounded(t *testing.T) {
+	var running, maxRunning atomic.Int32
+
+	s := NewServer(&ServerOpts{BatchConcurrency: 3})
+	s.Register(Query("slow", func(ctx context.Context, input struct{ N int }) (int, error) {
+		n := running.Add(1)
+		for {
+			current := maxRunning.Load()
+			if n <= current || maxRunning.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+		return input.N, nil
+	}))
+
+	items := make([]string, 10)
+	for i := range items {
+		items[i] = fmt.Sprintf(`{"key": "slow", "input": {"N": %d}}`, i)
+	}
+	results := doBatch(t, s, "["+strings.Join(items, ",")+"]")
+
+	for i, result := range results {
+		if string(result.Data) != fmt.Sprint(i) {
+			t.Errorf("results[%d] = %s, want %d", i, result.Data, i)
+		}
+	}
+	if maxRunning.Load() != 3 {
+		t.Errorf("max concurrent handlers = %d, want 3", maxRunning.Load())
+	}
+}
+
+// newOrderedServer returns a server whose "append" mutation takes less time
+// the larger its input, so running a batch of them concurrently would record
+// them out of order.
+func newOrderedServer(opts *ServerOpts) (*Server, func() []int) {
+	var mu sync.Mutex
+	var log []int
+	s := NewServer(opts)
+	s.Register(
+		Mutation("append", func(ctx context.Context, input struct{ N int }) (int, error) {
+			time.Sleep(time.Duration(10-input.N) * 5 * time.Millisecond)
+			mu.Lock()
+			defer mu.Unlock()
+			log = append(log, input.N)
+			return len(log), nil
+		}),
+		Query("count", func(ctx context.Context, input struct{}) (int, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(log), nil
+		}),
+	)
+	return s, func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(log)
+	}
+}
+
+func TestBatchRunsMutationsInOrder(t *testing.T) {
+	s, getLog := newOrderedServer(nil)
+
+	results := doBatch(t, s, `[
+		{"key": "append", "input": {"N": 0}},
+		{"key": "append", "input": {"N": 1}},
+		{"key": "count"},
+		{"key": "append", "input": {"N": 2}},
+		{"key": "append", "input": {"N": 3}}
+	]`)
+
+	if want := []int{0, 1, 2, 3}; !reflect.DeepEqual(getLog(), want) {
+		t.Errorf("mutations ran in order %v, want %v", getLog(), want)
+	}
+	for i, want := range []string{"1", "2", "2", "3", "4"} {
+		if string(results[i].Data) != want {
+			t.Errorf("results[%d] = %s, want %s", i, results[i].Data, want)
+		}
+	}
+}
+
+// Runs the generated client against a real server: mutations made in the
+// same tick must reach the server in call order, even when they are split
+// across several batches.
+func TestClientBatchKeepsMutationOrder(t *testing.T) {
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+	if err := exec.Command(node, "--experimental-strip-types", "-e", "").Run(); err != nil {
+		t.Skip("node does not support type stripping")
+	}
+
+	s, getLog := newOrderedServer(nil)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	client := buildClientTS(&ClientOpts{BaseURL: srv.URL + "/api/", Batch: true, MaxBatchSize: 3})
+	if err := os.WriteFile(filepath.Join(dir, "client.ts"), []byte(client), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	script := `import { mutate } from "./client.ts";
+await Promise.all([0, 1, 2, 3, 4, 5, 6, 7].map((N) => mutate("append", { N })));
+`
+	if err := os.WriteFile(filepath.Join(dir, "check.ts"), []byte(script), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	check := exec.Command(node, "--experimental-strip-types", "--no-warnings", "check.ts")
+	check.Dir = dir
+	if out, err := check.CombinedOutput(); err != nil {
+		t.Fatalf("node failed: %v\n%s", err, out)
+	}
+
+	if want := []int{0, 1, 2, 3, 4, 5, 6, 7}; !reflect.DeepEqual(getLog(), want) {
+		t.Errorf("mutations reached the server in order %v, want %v", getLog(), want)
+	}
+}
+
+func TestBatchRejects(t *testing.T) {
+	s := NewServer(&ServerOpts{MaxBatchSize: 2})
+	s.Register(Query("q", func(ctx context.Context, input struct{}) (int, error) { return 1, nil }))
+
+	tests := []struct {
+		name     string
+		method   string
+		body     string
+		wantCode int
+	}{
+		{"wrong method", http.MethodGet, "", http.StatusMethodNotAllowed},
+		{"malformed", http.MethodPost, `[{`, http.StatusBadRequest},
+		{"empty", http.MethodPost, `[]`, http.StatusBadRequest},
+		{"too large", http.MethodPost, `[{"key":"q"},{"key":"q"},{"key":"q"}]`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := do(s, tt.method, "/api/"+BatchKey, tt.body); rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantCode)
+			}
+		})
+	}
+
+	disabled := NewServer(&ServerOpts{DisableBatch: true})
+	if rec := do(disabled, http.MethodPost, "/api/"+BatchKey, `[{"key":"q"}]`); rec.Code != http.StatusNotFound {
+		t.Errorf("disabled batch: status = %d, want 404", rec.Code)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() should panic on the reserved batch key")
+		}
+	}()
+	s.Register(Query(BatchKey, func(ctx context.Context, input struct{}) (int, error) { return 1, nil }))
+}
+
+func TestGenerateTypeScriptClientBatch(t *testing.T) {
+	ts := normalizeWhiteSpace(buildClientTS(&ClientOpts{Batch: true, MaxBatchSize: 10}))
+	for _, expectedStr := range []string{
+		`batch: true,`,
+		`maxBatchSize: 10,`,
+		`const res = await send("_batch", {`,
+	} {
+		if !strings.Contains(ts, normalizeWhiteSpace(expectedStr)) {
+			t.Errorf("Expected string not found in generated client: %s", expectedStr)
+		}
+	}
+
+	if ts := buildClientTS(&ClientOpts{}); !strings.Contains(ts, "batch: false,") {
+		t.Error("batching should be off by default")
+	}
+}
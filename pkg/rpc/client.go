@@ -0,0 +1,344 @@
+package rpc
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+type ClientOpts struct {
+	// Optional. Defaults to "/api/". Prepended to each route key to form the
+	// request URL, so it should match ServerOpts.BasePath (optionally with an
+	// origin in front). Can be overridden at runtime with configureClient.
+	BaseURL string
+
+	// Optional. Defaults to "X-CSRF-Token". The header in which mutations
+	// (and batches) send the token returned by the client's getCSRFToken, if
+	// any.
+	CSRFHeaderName string
+
+	// Optional. Defaults to false. Set to true to have the client coalesce
+	// queries and mutations made in the same tick into a single batch request
+	// (see BatchKey). Calls made with an abort signal are never batched.
+	// Mutations still reach the server in the order they were made. Can be
+	// overridden at runtime with configureClient.
+	Batch bool
+
+	// Optional. Defaults to 32. Larger batches are split up. Should not
+	// exceed ServerOpts.MaxBatchSize.
+	MaxBatchSize int
+}
+
+const defaultCSRFHeaderName = "X-CSRF-Token"
+
+// buildClientTS returns the TypeScript for a fetch client that calls the
+// routes served by a Server.
+func buildClientTS(opts *ClientOpts) string {
+	baseURL := opts.BaseURL
+	if baseURL == "" {
+		baseURL = defaultBasePath
+	}
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	csrfHeaderName := opts.CSRFHeaderName
+	if csrfHeaderName == "" {
+		csrfHeaderName = defaultCSRFHeaderName
+	}
+
+	maxBatchSize := opts.MaxBatchSize
+	if maxBatchSize <= 0 {
+		maxBatchSize = defaultMaxBatchSize
+	}
+
+	var sb strings.Builder
+	err := clientTmpl.Execute(&sb, map[string]string{
+		"BaseURL":        mustJSONString(baseURL),
+		"CSRFHeaderName": mustJSONString(csrfHeaderName),
+		"BatchKey":       mustJSONString(BatchKey),
+		"Batch":          strconv.FormatBool(opts.Batch),
+		"MaxBatchSize":   strconv.Itoa(maxBatchSize),
+	})
+	if err != nil {
+		panic(err)
+	}
+	return sb.String()
+}
+
+func mustJSONString(s string) string {
+	b, err := json.Marshal(s)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
+
+var clientTmpl = template.Must(template.New("client").Parse(clientTmplStr))
+
+// The query string serialization mirrors what validate.URLSearchParamsInto
+// expects: nested objects and maps use dotted keys ("a.b=1"), arrays repeat
+// their key ("tags=a&tags=b"), and null or undefined values are omitted.
+const clientTmplStr = `export type ClientConfig = {
+	baseURL: string;
+	batch: boolean;
+	maxBatchSize: number;
+	getCSRFToken?: () => string | null | undefined;
+	headers?: Record<string, string>;
+	fetch?: typeof fetch;
+};
+
+const clientConfig: ClientConfig = {
+	baseURL: {{ .BaseURL }},
+	batch: {{ .Batch }},
+	maxBatchSize: {{ .MaxBatchSize }},
+};
+
+export function configureClient(config: Partial<ClientConfig>): void {
+	Object.assign(clientConfig, config);
+}
+
+export type RequestOptions = { signal?: AbortSignal };
+
+export type RPCErrorShape = { code: string; message: string; details?: unknown };
+
+export class RPCError<E extends RPCErrorShape = RPCErrorShape> extends Error {
+	readonly key: string;
+	readonly status: number;
+	readonly code: E["code"];
+	readonly details: E["details"];
+
+	constructor(key: string, status: number, error: E) {
+		super(error.message);
+		this.name = "RPCError";
+		this.key = key;
+		this.status = status;
+		this.code = error.code;
+		this.details = error.details;
+	}
+}
+
+export function isRPCError(err: unknown): err is RPCError {
+	return err instanceof RPCError;
+}
+
+export function isQueryError<K extends QueryAPIKey>(err: unknown, key: K): err is RPCError<QueryAPIError<K>> {
+	return err instanceof RPCError && err.key === key;
+}
+
+export function isMutationError<K extends MutationAPIKey>(err: unknown, key: K): err is RPCError<MutationAPIError<K>> {
+	return err instanceof RPCError && err.key === key;
+}
+
+export function toSearchParams(input: unknown): URLSearchParams {
+	const params = new URLSearchParams();
+	appendSearchParams(params, "", input);
+	return params;
+}
+
+function appendSearchParams(params: URLSearchParams, key: string, value: unknown): void {
+	if (value === null || value === undefined) {
+		return;
+	}
+	if (Array.isArray(value)) {
+		for (const item of value) {
+			if (item !== null && item !== undefined) {
+				params.append(key, String(item));
+			}
+		}
+		return;
+	}
+	if (typeof value === "object") {
+		for (const [k, v] of Object.entries(value)) {
+			appendSearchParams(params, key ? key + "." + k : k, v);
+		}
+		return;
+	}
+	params.append(key, String(value));
+}
+
+async function send(key: string, init: RequestInit, search?: URLSearchParams): Promise<Response> {
+	let url = clientConfig.baseURL + key;
+	const qs = search?.toString();
+	if (qs) {
+		url += "?" + qs;
+	}
+	const doFetch = clientConfig.fetch ?? fetch;
+	const res = await doFetch(url, {
+		credentials: "same-origin",
+		...init,
+		headers: { ...clientConfig.headers, ...(init.headers as Record<string, string> | undefined) },
+	});
+	if (!res.ok) {
+		let error: RPCErrorShape;
+		try {
+			error = await res.json();
+		} catch {
+			error = { code: "INTERNAL", message: res.statusText };
+		}
+		throw new RPCError(key, res.status, error);
+	}
+	return res;
+}
+
+async function call(key: string, init: RequestInit, search?: URLSearchParams): Promise<unknown> {
+	const res = await send(key, init, search);
+	return res.json();
+}
+
+function jsonPostHeaders(): Record<string, string> {
+	const headers: Record<string, string> = { "Content-Type": "application/json" };
+	const csrfToken = clientConfig.getCSRFToken?.();
+	if (csrfToken) {
+		headers[{{ .CSRFHeaderName }}] = csrfToken;
+	}
+	return headers;
+}
+
+type PendingCall = {
+	key: string;
+	input: unknown;
+	mutation: boolean;
+	direct: () => Promise<unknown>;
+	resolve: (value: unknown) => void;
+	reject: (reason: unknown) => void;
+};
+
+let pendingCalls: PendingCall[] = [];
+
+// Calls made in the same tick are queued, then sent together in one batch
+// request once the current task yields.
+function enqueue(
+	key: string,
+	input: unknown,
+	mutation: boolean,
+	direct: () => Promise<unknown>,
+	signal?: AbortSignal,
+): Promise<unknown> {
+	if (!clientConfig.batch || signal) {
+		return direct();
+	}
+	return new Promise((resolve, reject) => {
+		pendingCalls.push({ key, input, mutation, direct, resolve, reject });
+		if (pendingCalls.length === 1) {
+			queueMicrotask(() => void flushPendingCalls());
+		}
+	});
+}
+
+// The server runs a batch containing a mutation in order, but separate
+// batches race each other, so those are sent one after another.
+async function flushPendingCalls(): Promise<void> {
+	const calls = pendingCalls;
+	pendingCalls = [];
+	const inOrder = calls.some((c) => c.mutation);
+	for (let i = 0; i < calls.length; i += clientConfig.maxBatchSize) {
+		const batch = runBatch(calls.slice(i, i + clientConfig.maxBatchSize));
+		if (inOrder) {
+			await batch;
+		}
+	}
+}
+
+async function runBatch(calls: PendingCall[]): Promise<void> {
+	const first = calls[0];
+	if (calls.length === 1 && first) {
+		await first.direct().then(first.resolve, first.reject);
+		return;
+	}
+	try {
+		const res = await send({{ .BatchKey }}, {
+			method: "POST",
+			headers: jsonPostHeaders(),
+			body: JSON.stringify(calls.map((c) => ({ key: c.key, input: c.input ?? null }))),
+		});
+		const results = (await res.json()) as { data?: unknown; error?: RPCErrorShape; status?: number }[];
+		calls.forEach((c, i) => {
+			const result = results[i];
+			if (result?.error) {
+				c.reject(new RPCError(c.key, result.status ?? res.status, result.error));
+			} else {
+				c.resolve(result?.data);
+			}
+		});
+	} catch (err) {
+		for (const c of calls) {
+			c.reject(err);
+		}
+	}
+}
+
+export function query<K extends QueryAPIKey>(
+	key: K,
+	input: QueryAPIInput<K>,
+	options?: RequestOptions,
+): Promise<QueryAPIOutput<K>> {
+	const direct = () => call(key, { method: "GET", signal: options?.signal }, toSearchParams(input));
+	return enqueue(key, input, false, direct, options?.signal) as Promise<QueryAPIOutput<K>>;
+}
+
+export function mutate<K extends MutationAPIKey>(
+	key: K,
+	input: MutationAPIInput<K>,
+	options?: RequestOptions,
+): Promise<MutationAPIOutput<K>> {
+	const direct = () =>
+		call(key, {
+			method: "POST",
+			headers: jsonPostHeaders(),
+			body: JSON.stringify(input),
+			signal: options?.signal,
+		});
+	return enqueue(key, input, true, direct, options?.signal) as Promise<MutationAPIOutput<K>>;
+}
+
+export function isSubscriptionError<K extends SubscriptionAPIKey>(
+	err: unknown,
+	key: K,
+): err is RPCError<SubscriptionAPIError<K>> {
+	return err instanceof RPCError && err.key === key;
+}
+
+// Yields each message as it arrives. Breaking out of the loop (or aborting
+// the signal) closes the connection.
+export async function* subscribe<K extends SubscriptionAPIKey>(
+	key: K,
+	input: SubscriptionAPIInput<K>,
+	options?: RequestOptions,
+): AsyncGenerator<SubscriptionAPIOutput<K>, void, undefined> {
+	const res = await send(
+		key,
+		{ method: "GET", headers: { Accept: "application/x-ndjson" }, signal: options?.signal },
+		toSearchParams(input),
+	);
+	if (!res.body) {
+		return;
+	}
+	const reader = res.body.pipeThrough(new TextDecoderStream()).getReader();
+	let buffer = "";
+	try {
+		for (;;) {
+			const { value, done } = await reader.read();
+			if (done) {
+				return;
+			}
+			buffer += value;
+			let newline = buffer.indexOf("\n");
+			while (newline !== -1) {
+				const line = buffer.slice(0, newline).trim();
+				buffer = buffer.slice(newline + 1);
+				newline = buffer.indexOf("\n");
+				if (!line) {
+					continue;
+				}
+				const frame = JSON.parse(line) as { data?: unknown; error?: RPCErrorShape };
+				if (frame.error) {
+					throw new RPCError(key, res.status, frame.error);
+				}
+				yield frame.data as SubscriptionAPIOutput<K>;
+			}
+		}
+	} finally {
+		reader.cancel().catch(() => {});
+	}
+}`
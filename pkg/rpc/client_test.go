@@ -0,0 +1,98 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGenerateTypeScriptClient(t *testing.T) {
+	outPath := filepath.Join(t.TempDir(), testFileName)
+
+	s := newTestServer(nil)
+	err := GenerateTypeScript(Opts{
+		OutPath:   outPath,
+		RouteDefs: s.RouteDefs(),
+		Client:    &ClientOpts{BaseURL: "https://example.com/rpc", CSRFHeaderName: "X-My-CSRF"},
+	})
+	if err != nil {
+		t.Fatalf("GenerateTypeScript failed: %s", err)
+	}
+
+	content, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("Failed to read generated TypeScript file: %s", err)
+	}
+	contentStr := normalizeWhiteSpace(string(content))
+
+	for _, expectedStr := range []string{
+		`baseURL: "https://example.com/rpc/",`,
+		`export function query<K extends QueryAPIKey>( key: K, input: QueryAPIInput<K>, options?: RequestOptions, ): Promise<QueryAPIOutput<K>>`,
+		`export function mutate<K extends MutationAPIKey>( key: K, input: MutationAPIInput<K>, options?: RequestOptions, ): Promise<MutationAPIOutput<K>>`,
+		`headers["X-My-CSRF"] = csrfToken;`,
+		`export class RPCError<E extends RPCErrorShape = RPCErrorShape> extends Error {`,
+	} {
+		if !strings.Contains(contentStr, normalizeWhiteSpace(expectedStr)) {
+			t.Errorf("Expected string not found in generated TypeScript content: %s", expectedStr)
+		}
+	}
+}
+
+func TestGenerateTypeScriptClientDefaults(t *testing.T) {
+	ts := buildClientTS(&ClientOpts{})
+	if !strings.Contains(ts, `baseURL: "/api/"`) {
+		t.Error("client should default to the server's default base path")
+	}
+	if !strings.Contains(ts, `headers["X-CSRF-Token"]`) {
+		t.Error("client should default to the X-CSRF-Token header")
+	}
+
+	outPath := filepath.Join(t.TempDir(), testFileName)
+	if err := GenerateTypeScript(Opts{OutPath: outPath}); err != nil {
+		t.Fatalf("GenerateTypeScript failed: %s", err)
+	}
+	content, _ := os.ReadFile(outPath)
+	if strings.Contains(string(content), "export function query") {
+		t.Error("client should only be emitted when ClientOpts is set")
+	}
+}
+
+// The generated client's toSearchParams serializes
+// { name: "a b", ok: true, tags: ["x", "y"], nested: { c: 1, d: { e: 2 } } }
+// as below. The server must decode it back into the same value.
+func TestClientSearchParamsCompatibility(t *testing.T) {
+	type deeper struct {
+		E int `json:"e"`
+	}
+	type nested struct {
+		C int    `json:"c"`
+		D deeper `json:"d"`
+	}
+	type input struct {
+		Name   string   `json:"name"`
+		OK     bool     `json:"ok"`
+		Tags   []string `json:"tags"`
+		Nested nested   `json:"nested"`
+	}
+
+	var got input
+	s := NewServer(nil)
+	s.Register(Query("search", func(ctx context.Context, in input) (bool, error) {
+		got = in
+		return true, nil
+	}))
+
+	rec := do(s, http.MethodGet, "/api/search?name=a+b&ok=true&tags=x&tags=y&nested.c=1&nested.d.e=2", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body: %s)", rec.Code, rec.Body)
+	}
+
+	want := input{Name: "a b", OK: true, Tags: []string{"x", "y"}, Nested: nested{C: 1, D: deeper{E: 2}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decoded input = %+v, want %+v", got, want)
+	}
+}
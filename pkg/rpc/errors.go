@@ -0,0 +1,184 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type ErrorCode = string
+
+// Built-in error codes. Any route may return these, whether or not it
+// declares them.
+const (
+	ErrorCodeBadRequest       ErrorCode = "BAD_REQUEST"        // input could not be decoded
+	ErrorCodeValidation       ErrorCode = "VALIDATION"         // input failed validation; details are []FieldError
+	ErrorCodeNotFound         ErrorCode = "NOT_FOUND"          // no route for the key
+	ErrorCodeMethodNotAllowed ErrorCode = "METHOD_NOT_ALLOWED" // wrong HTTP method for the route's action type
+	ErrorCodeInternal         ErrorCode = "INTERNAL"           // the handler failed with a non-*Error error
+)
+
+var builtinErrorCodes = []ErrorCode{
+	ErrorCodeBadRequest,
+	ErrorCodeValidation,
+	ErrorCodeNotFound,
+	ErrorCodeMethodNotAllowed,
+	ErrorCodeInternal,
+}
+
+// Error is an error that is sent to the client as is, as JSON. Handlers may
+// return an *Error (optionally wrapped) to control exactly what the client
+// sees. Any other error is logged via ServerOpts.OnError and sent to the
+// client as a generic ErrorCodeInternal error.
+type Error struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+	Details any       `json:"details,omitempty"` // must be JSON-serializable
+
+	// Optional. The HTTP status to respond with. Defaults to 500 for
+	// ErrorCodeInternal, 404 for ErrorCodeNotFound, 405 for
+	// ErrorCodeMethodNotAllowed, and 400 otherwise.
+	Status int `json:"-"`
+}
+
+func NewError(code ErrorCode, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rpc: %s: %s", e.Code, e.Message)
+}
+
+// WithDetails sets the error's details and returns the error.
+func (e *Error) WithDetails(details any) *Error {
+	e.Details = details
+	return e
+}
+
+// WithStatus sets the error's HTTP status and returns the error.
+func (e *Error) WithStatus(status int) *Error {
+	e.Status = status
+	return e
+}
+
+func (e *Error) status() int {
+	if e.Status != 0 {
+		return e.Status
+	}
+	switch e.Code {
+	case ErrorCodeInternal:
+		return http.StatusInternalServerError
+	case ErrorCodeNotFound:
+		return http.StatusNotFound
+	case ErrorCodeMethodNotAllowed:
+		return http.StatusMethodNotAllowed
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+func writeError(w http.ResponseWriter, e *Error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(e.status())
+	json.NewEncoder(w).Encode(e)
+}
+
+// FieldError describes a single failed validation rule. It is the shape of
+// each item in the details of an ErrorCodeValidation error.
+type FieldError struct {
+	Path    string `json:"path"`            // e.g., "user.tags[0]", using JSON field names
+	Tag     string `json:"tag"`             // e.g., "required" or "min"
+	Param   string `json:"param,omitempty"` // e.g., "3" for "min=3"
+	Message string `json:"message"`
+}
+
+// inputError converts an error from decoding a route's input into an *Error.
+func inputError(err error, inputType reflect.Type) *Error {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return NewError(ErrorCodeValidation, "input failed validation").
+			WithDetails(flattenValidationErrors(validationErrs, inputType))
+	}
+	return NewError(ErrorCodeBadRequest, err.Error())
+}
+
+func flattenValidationErrors(errs validator.ValidationErrors, inputType reflect.Type) []FieldError {
+	fieldErrs := make([]FieldError, 0, len(errs))
+	for _, fe := range errs {
+		path := jsonFieldPath(inputType, fe.StructNamespace())
+
+		rule := fe.Tag()
+		if fe.Param() != "" {
+			rule += "=" + fe.Param()
+		}
+
+		fieldErrs = append(fieldErrs, FieldError{
+			Path:    path,
+			Tag:     fe.Tag(),
+			Param:   fe.Param(),
+			Message: fmt.Sprintf("%s failed on the '%s' rule", path, rule),
+		})
+	}
+	return fieldErrs
+}
+
+// jsonFieldPath converts a validator struct namespace (e.g.,
+// "input.Nested.Tags[0]") into a path of JSON field names (e.g.,
+// "nested.tags[0]"). Fields of embedded structs without a JSON name are
+// promoted, as they are by encoding/json.
+func jsonFieldPath(t reflect.Type, structNamespace string) string {
+	_, rest, ok := strings.Cut(structNamespace, ".")
+	if !ok {
+		return structNamespace
+	}
+
+	var parts []string
+	for _, part := range strings.Split(rest, ".") {
+		name, index, hasIndex := strings.Cut(part, "[")
+
+		for t != nil && t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+
+		jsonName := name
+		var next reflect.Type
+		if t != nil && t.Kind() == reflect.Struct {
+			if f, ok := t.FieldByName(name); ok {
+				next = f.Type
+				tagName, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+				switch {
+				case tagName != "":
+					jsonName = tagName
+				case f.Anonymous:
+					jsonName = ""
+				}
+			}
+		}
+
+		if hasIndex {
+			jsonName += "[" + index
+			for next != nil && next.Kind() == reflect.Pointer {
+				next = next.Elem()
+			}
+			if next != nil {
+				switch next.Kind() {
+				case reflect.Slice, reflect.Array, reflect.Map:
+					next = next.Elem()
+				}
+			}
+		}
+
+		if jsonName != "" {
+			parts = append(parts, jsonName)
+		}
+		t = next
+	}
+
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,160 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func decodeError(t *testing.T, body string) Error {
+	t.Helper()
+	var e Error
+	if err := json.Unmarshal([]byte(body), &e); err != nil {
+		t.Fatalf("failed to decode error body %q: %v", body, err)
+	}
+	return e
+}
+
+func TestJSONFieldPath(t *testing.T) {
+	type Base struct {
+		ID int `json:"id"`
+	}
+	type item struct {
+		Label string `json:"label"`
+	}
+	type input struct {
+		Base
+		Name   string `json:"name,omitempty"`
+		NoTag  string
+		Items  []item            `json:"items"`
+		Ptr    *item             `json:"ptr"`
+		Lookup map[string]string `json:"lookup"`
+	}
+
+	tests := []struct {
+		namespace string
+		want      string
+	}{
+		{"input.Name", "name"},
+		{"input.NoTag", "NoTag"},
+		{"input.Base.ID", "id"},
+		{"input.Items[2].Label", "items[2].label"},
+		{"input.Ptr.Label", "ptr.label"},
+		{"input.Lookup[k]", "lookup[k]"},
+		{"input.Unknown.Field", "Unknown.Field"},
+	}
+
+	for _, tt := range tests {
+		if got := jsonFieldPath(reflect.TypeFor[input](), tt.namespace); got != tt.want {
+			t.Errorf("jsonFieldPath(%q) = %q, want %q", tt.namespace, got, tt.want)
+		}
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	s := newTestServer(nil)
+	s.Register(
+		Mutation("claim", func(ctx context.Context, input renameInput) (bool, error) {
+			err := NewError("TAKEN", "name is taken").WithStatus(http.StatusConflict).WithDetails(map[string]string{"owner": "bob"})
+			return false, fmt.Errorf("claiming: %w", err)
+		}).WithErrors("TAKEN"),
+	)
+
+	t.Run("validation", func(t *testing.T) {
+		rec := do(s, http.MethodPost, "/api/rename", `{"id":1}`)
+		if rec.Code != http.StatusBadRequest || rec.Header().Get("Content-Type") != "application/json" {
+			t.Fatalf("status = %d, Content-Type = %q, want 400 JSON", rec.Code, rec.Header().Get("Content-Type"))
+		}
+		var e struct {
+			Code    string       `json:"code"`
+			Details []FieldError `json:"details"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil {
+			t.Fatalf("failed to decode error: %v", err)
+		}
+		if e.Code != ErrorCodeValidation || len(e.Details) != 1 {
+			t.Fatalf("error = %+v, want one validation detail", e)
+		}
+		if d := e.Details[0]; d.Path != "name" || d.Tag != "required" {
+			t.Errorf("detail = %+v, want path name and tag required", d)
+		}
+	})
+
+	t.Run("bad request", func(t *testing.T) {
+		rec := do(s, http.MethodPost, "/api/rename", `{"id":`)
+		if e := decodeError(t, rec.Body.String()); rec.Code != http.StatusBadRequest || e.Code != ErrorCodeBadRequest {
+			t.Errorf("got %d %+v, want 400 %s", rec.Code, e, ErrorCodeBadRequest)
+		}
+	})
+
+	t.Run("handler *Error", func(t *testing.T) {
+		rec := do(s, http.MethodPost, "/api/claim", `{"id":1,"name":"x"}`)
+		e := decodeError(t, rec.Body.String())
+		if rec.Code != http.StatusConflict || e.Code != "TAKEN" || e.Message != "name is taken" {
+			t.Errorf("got %d %+v, want 409 TAKEN", rec.Code, e)
+		}
+		if details, _ := e.Details.(map[string]any); details["owner"] != "bob" {
+			t.Errorf("details = %v, want owner bob", e.Details)
+		}
+	})
+
+	t.Run("internal", func(t *testing.T) {
+		rec := do(s, http.MethodPost, "/api/rename", `{"id":13,"name":"x"}`)
+		e := decodeError(t, rec.Body.String())
+		if rec.Code != http.StatusInternalServerError || e.Code != ErrorCodeInternal || strings.Contains(e.Message, "unlucky") {
+			t.Errorf("got %d %+v, want an opaque 500 %s", rec.Code, e, ErrorCodeInternal)
+		}
+	})
+
+	t.Run("not found and method not allowed", func(t *testing.T) {
+		rec := do(s, http.MethodGet, "/api/nope", "")
+		if e := decodeError(t, rec.Body.String()); e.Code != ErrorCodeNotFound {
+			t.Errorf("code = %s, want %s", e.Code, ErrorCodeNotFound)
+		}
+		rec = do(s, http.MethodGet, "/api/rename", "")
+		if e := decodeError(t, rec.Body.String()); e.Code != ErrorCodeMethodNotAllowed {
+			t.Errorf("code = %s, want %s", e.Code, ErrorCodeMethodNotAllowed)
+		}
+	})
+}
+
+func TestGenerateTypeScriptErrors(t *testing.T) {
+	outPath := filepath.Join(t.TempDir(), testFileName)
+
+	route := Query("greet", func(ctx context.Context, input greetInput) (greetOutput, error) {
+		return greetOutput{}, nil
+	}).WithErrors("RATE_LIMITED", "BANNED", "RATE_LIMITED")
+
+	if got := route.Def().Errors; !reflect.DeepEqual(got, []ErrorCode{"RATE_LIMITED", "BANNED"}) {
+		t.Errorf("Errors = %v, want deduplicated codes in declaration order", got)
+	}
+
+	err := GenerateTypeScript(Opts{OutPath: outPath, RouteDefs: []RouteDef{route.Def()}})
+	if err != nil {
+		t.Fatalf("GenerateTypeScript failed: %s", err)
+	}
+
+	content, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("Failed to read generated TypeScript file: %s", err)
+	}
+	contentStr := normalizeWhiteSpace(string(content))
+
+	for _, expectedStr := range []string{
+		`errorCodes: ["RATE_LIMITED","BANNED"],`,
+		`export type RPCBuiltinErrorCode = "BAD_REQUEST" | "VALIDATION" | "NOT_FOUND" | "METHOD_NOT_ALLOWED" | "INTERNAL";`,
+		`details?: K extends "VALIDATION" ? RPCFieldError[] : unknown`,
+		`export type QueryAPIError<T extends QueryAPIKey> = RPCErrorUnion<Extract<QueryAPIRoute, { key: T }> extends { errorCodes: readonly (infer C extends string)[] } ? C : never>;`,
+		`export type MutationAPIError<T extends MutationAPIKey> =`,
+	} {
+		if !strings.Contains(contentStr, normalizeWhiteSpace(expectedStr)) {
+			t.Errorf("Expected string not found in generated TypeScript content: %s", expectedStr)
+		}
+	}
+}
@@ -0,0 +1,554 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sjc5/kit/pkg/fsutil"
+	"github.com/sjc5/kit/pkg/tsgen/tsgencore"
+)
+
+const openAPIVersion = "3.1.0"
+
+type OpenAPIOpts struct {
+	// Path, including filename, where the resulting JSON document will be written
+	OutPath   string
+	RouteDefs []RouteDef
+
+	// Optional. Defaults to "API".
+	Title string
+	// Optional. Defaults to "0.0.0".
+	Version string
+	// Optional. Defaults to "/api/". Should match ServerOpts.BasePath.
+	BasePath string
+	// Optional. Should match Opts.TypeOverrides. Each overridden type gets the
+	// closest matching schema for its TypeScript, as for ts_type tags.
+	TypeOverrides map[reflect.Type]string
+}
+
+// GenerateOpenAPI writes an OpenAPI 3.1 document describing the routes to
+// opts.OutPath. See GenerateOpenAPIContent.
+func GenerateOpenAPI(opts OpenAPIOpts) error {
+	if opts.OutPath == "" {
+		return errors.New("outpath is required")
+	}
+
+	content, err := GenerateOpenAPIContent(opts)
+	if err != nil {
+		return err
+	}
+
+	err = fsutil.EnsureDir(filepath.Dir(opts.OutPath))
+	if err != nil {
+		return errors.New("failed to ensure out dest dir: " + err.Error())
+	}
+
+	err = os.WriteFile(opts.OutPath, content, os.ModePerm)
+	if err != nil {
+		return errors.New("failed to write openapi file: " + err.Error())
+	}
+
+	return nil
+}
+
+// GenerateOpenAPIContent returns an OpenAPI 3.1 document describing the
+// routes, as JSON.
+//
+// Schemas follow the generated TypeScript: named structs become components
+// with the same (possibly suffixed) names, fields follow their json tags, and
+// fields that are optional in TypeScript are not required. Fields with a
+// ts_type override get the closest matching schema. Validate tags refine the
+// schemas where JSON Schema has an equivalent: required, min, max, len, and
+// oneof (including after dive, for elements).
+//
+// Queries and subscriptions take their input as query params, with nested
+// struct fields flattened to dotted names, as the generated client sends them.
+// Mutations take a JSON request body. Each route's default response is its
+// error union.
+func GenerateOpenAPIContent(opts OpenAPIOpts) ([]byte, error) {
+	title := opts.Title
+	if title == "" {
+		title = "API"
+	}
+	version := opts.Version
+	if version == "" {
+		version = "0.0.0"
+	}
+	basePath := opts.BasePath
+	if basePath == "" {
+		basePath = defaultBasePath
+	}
+	if !strings.HasPrefix(basePath, "/") {
+		basePath = "/" + basePath
+	}
+	if !strings.HasSuffix(basePath, "/") {
+		basePath += "/"
+	}
+
+	b := newSchemaBuilder(opts.RouteDefs, opts.TypeOverrides)
+
+	paths := make(map[string]any, len(opts.RouteDefs))
+	for _, r := range opts.RouteDefs {
+		op := b.operation(r)
+		paths[basePath+r.Key] = map[string]any{strings.ToLower(methodForActionType(r.ActionType)): op}
+	}
+
+	b.components[fieldErrorSchemaName] = jsonSchema{
+		"type": "object",
+		"properties": map[string]any{
+			"path":    jsonSchema{"type": "string"},
+			"tag":     jsonSchema{"type": "string"},
+			"param":   jsonSchema{"type": "string"},
+			"message": jsonSchema{"type": "string"},
+		},
+		"required": []string{"path", "tag", "message"},
+	}
+
+	doc := map[string]any{
+		"openapi":    openAPIVersion,
+		"info":       map[string]any{"title": title, "version": version},
+		"paths":      paths,
+		"components": map[string]any{"schemas": b.components},
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+const fieldErrorSchemaName = "RPCFieldError"
+
+type jsonSchema = map[string]any
+
+type schemaBuilder struct {
+	names         map[reflect.Type]string // named struct types to their resolved TypeScript names
+	components    map[string]any
+	typeOverrides map[reflect.Type]string
+}
+
+func newSchemaBuilder(routeDefs []RouteDef, typeOverrides map[reflect.Type]string) *schemaBuilder {
+	adHocTypes := make([]*tsgencore.AdHocType, 0, len(routeDefs)*2)
+	for _, r := range routeDefs {
+		adHocTypes = append(adHocTypes,
+			&tsgencore.AdHocType{TypeInstance: r.Input},
+			&tsgencore.AdHocType{TypeInstance: r.Output},
+		)
+	}
+
+	b := &schemaBuilder{names: map[reflect.Type]string{}, components: map[string]any{}, typeOverrides: typeOverrides}
+	for _, typeInfo := range tsgencore.ProcessTypesWithOpts(adHocTypes, tsgencore.ProcessTypesOpts{TypeOverrides: typeOverrides}).Types {
+		t := typeInfo.ReflectType
+		if t != nil && t.Kind() == reflect.Struct && typeInfo.ResolvedName != "" {
+			b.names[t] = typeInfo.ResolvedName
+		}
+	}
+	return b
+}
+
+func (b *schemaBuilder) operation(r RouteDef) map[string]any {
+	op := map[string]any{"operationId": r.Key, "tags": []string{r.ActionType}}
+
+	inputType := reflect.TypeOf(r.Input)
+	if r.ActionType == ActionTypeMutation {
+		op["requestBody"] = map[string]any{
+			"required": true,
+			"content":  map[string]any{"application/json": map[string]any{"schema": b.schemaFor(inputType)}},
+		}
+	} else if params := b.queryParams(inputType, "", false); len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	outputSchema := b.schemaFor(reflect.TypeOf(r.Output))
+	var content map[string]any
+	if r.ActionType == ActionTypeSubscription {
+		// Each message is sent as it would be in a regular response, wrapped
+		// in an NDJSON line or an SSE event
+		content = map[string]any{
+			contentTypeNDJSON: map[string]any{"schema": jsonSchema{
+				"type":       "object",
+				"properties": map[string]any{"data": outputSchema, "error": errorSchema(r.Errors)},
+			}},
+			contentTypeSSE: map[string]any{"schema": jsonSchema{"type": "string"}},
+		}
+	} else {
+		content = map[string]any{"application/json": map[string]any{"schema": outputSchema}}
+	}
+
+	op["responses"] = map[string]any{
+		strconv.Itoa(http.StatusOK): map[string]any{"description": "OK", "content": content},
+		"default": map[string]any{
+			"description": "Error",
+			"content":     map[string]any{"application/json": map[string]any{"schema": errorSchema(r.Errors)}},
+		},
+	}
+	return op
+}
+
+func errorSchema(codes []ErrorCode) jsonSchema {
+	enum := append(append([]ErrorCode{}, builtinErrorCodes...), codes...)
+	return jsonSchema{
+		"type": "object",
+		"properties": map[string]any{
+			"code":    jsonSchema{"type": "string", "enum": enum},
+			"message": jsonSchema{"type": "string"},
+			"details": jsonSchema{},
+		},
+		"required": []string{"code", "message"},
+		// Other errors may carry details of any shape
+		"if": jsonSchema{
+			"properties": map[string]any{"code": jsonSchema{"const": ErrorCodeValidation}},
+			"required":   []string{"code"},
+		},
+		"then": jsonSchema{
+			"properties": map[string]any{
+				"details": jsonSchema{
+					"type":  "array",
+					"items": jsonSchema{"$ref": "#/components/schemas/" + fieldErrorSchemaName},
+				},
+			},
+		},
+	}
+}
+
+// queryParams flattens a struct's fields to query params, with nested
+// struct fields named by dotted paths. Fields nested in an optional field
+// are never required.
+func (b *schemaBuilder) queryParams(t reflect.Type, prefix string, optional bool) []any {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var params []any
+	b.eachField(t, func(field reflect.StructField, name string, required bool) {
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		opaque := b.hasOpaqueSchema(fieldType)
+		if fieldType.Kind() == reflect.Struct && fieldType != reflect.TypeFor[time.Time]() && !opaque && tsgencore.CustomTypeScriptType(field) == "" {
+			params = append(params, b.queryParams(fieldType, prefix+name+".", optional || !required)...)
+			return
+		}
+
+		param := map[string]any{
+			"name":     prefix + name,
+			"in":       "query",
+			"required": required && !optional,
+			"schema":   b.fieldSchema(field),
+		}
+		if k := fieldType.Kind(); (k == reflect.Slice || k == reflect.Array) && !opaque {
+			param["style"] = "form"
+			param["explode"] = true
+		}
+		params = append(params, param)
+	})
+	return params
+}
+
+// eachField calls fn for each field of struct type t that appears in its
+// JSON, in the same way as the generated TypeScript: embedded structs are
+// flattened, and embedded struct pointers are optional fields.
+func (b *schemaBuilder) eachField(t reflect.Type, fn func(field reflect.StructField, name string, required bool)) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.PkgPath != "" || tsgencore.ShouldOmitField(field) {
+			continue
+		}
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			b.eachField(field.Type, fn)
+			continue
+		}
+
+		name := tsgencore.JSONFieldName(field)
+		if name == "" {
+			continue
+		}
+
+		required := !tsgencore.IsOptionalField(field) && !tsgencore.IsMarkedOptional(field.Type)
+		if hasValidateRule(field, "required") {
+			required = true
+		}
+		fn(field, name, required)
+	}
+}
+
+func (b *schemaBuilder) schemaFor(t reflect.Type) jsonSchema {
+	if t == nil {
+		return jsonSchema{}
+	}
+
+	s := b.baseSchemaFor(t)
+	if tsgencore.IsMarkedNullable(t) {
+		if typ, ok := s["type"].(string); ok {
+			s["type"] = []string{typ, "null"}
+		} else {
+			s = jsonSchema{"anyOf": []any{s, jsonSchema{"type": "null"}}}
+		}
+	}
+	return s
+}
+
+func (b *schemaBuilder) baseSchemaFor(t reflect.Type) jsonSchema {
+	if override, ok := b.typeOverrides[t]; ok {
+		return tsTypeToSchema(override)
+	}
+	if tsgencore.IsByteSlice(t) {
+		return jsonSchema{"type": "string", "contentEncoding": "base64"}
+	}
+	if tsType, ok := tsgencore.MarshaledTypeScriptType(t); ok {
+		return tsTypeToSchema(tsType)
+	}
+
+	switch t {
+	case reflect.TypeFor[time.Time]():
+		return jsonSchema{"type": "string", "format": "date-time"}
+	case reflect.TypeFor[time.Duration]():
+		return jsonSchema{"type": "integer"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return jsonSchema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return enumSchema(t, "integer")
+	case reflect.Float32, reflect.Float64:
+		return jsonSchema{"type": "number"}
+	case reflect.String:
+		return enumSchema(t, "string")
+	case reflect.Ptr:
+		return b.schemaFor(t.Elem())
+	case reflect.Slice, reflect.Array:
+		return jsonSchema{"type": "array", "items": b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return jsonSchema{"type": "object", "additionalProperties": b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		name, ok := b.names[t]
+		if !ok {
+			return b.objectSchema(t)
+		}
+		if _, exists := b.components[name]; !exists {
+			b.components[name] = jsonSchema{} // placeholder, in case t is recursive
+			b.components[name] = b.objectSchema(t)
+		}
+		return jsonSchema{"$ref": "#/components/schemas/" + name}
+	default:
+		return jsonSchema{}
+	}
+}
+
+// enumSchema returns a schema of the given type that lists t's values if t is
+// an enum type (see tsgencore.TSEnumValuesMethodName).
+func enumSchema(t reflect.Type, typ string) jsonSchema {
+	s := jsonSchema{"type": typ}
+	if values := tsgencore.EnumValues(t); values != nil {
+		s["enum"] = values
+	}
+	return s
+}
+
+func (b *schemaBuilder) objectSchema(t reflect.Type) jsonSchema {
+	properties := map[string]any{}
+	var required []string
+
+	b.eachField(t, func(field reflect.StructField, name string, isRequired bool) {
+		properties[name] = b.fieldSchema(field)
+		if isRequired {
+			required = append(required, name)
+		}
+	})
+
+	s := jsonSchema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func (b *schemaBuilder) fieldSchema(field reflect.StructField) jsonSchema {
+	if custom := tsgencore.CustomTypeScriptType(field); custom != "" {
+		s := tsTypeToSchema(custom)
+		applyValidateRules(s, field.Type, field.Tag.Get("validate"))
+		return s
+	}
+
+	s := b.schemaFor(field.Type)
+	t := field.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if !b.hasOpaqueSchema(t) { // otherwise, the schema may not be the one the rules expect
+		applyValidateRules(s, field.Type, field.Tag.Get("validate"))
+	}
+	return s
+}
+
+// hasOpaqueSchema reports whether t's schema isn't derived from its Go
+// definition, because t has a type override or custom JSON marshalling (as
+// do byte slices, which are sent as base64 strings).
+func (b *schemaBuilder) hasOpaqueSchema(t reflect.Type) bool {
+	if _, ok := b.typeOverrides[t]; ok {
+		return true
+	}
+	_, marshaled := tsgencore.MarshaledTypeScriptType(t)
+	return marshaled || tsgencore.IsByteSlice(t)
+}
+
+/////////////////////////////////////////////////////////////////////
+/////// VALIDATE TAGS
+/////////////////////////////////////////////////////////////////////
+
+func hasValidateRule(field reflect.StructField, rule string) bool {
+	for r := range strings.SplitSeq(field.Tag.Get("validate"), ",") {
+		if r == "dive" {
+			return false
+		}
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// applyValidateRules folds the validate rules that have a JSON Schema
+// equivalent into s. Rules after "dive" apply to the elements of a slice,
+// array, or map. Alternatives ("a|b") are skipped.
+func applyValidateRules(s jsonSchema, t reflect.Type, tag string) {
+	if tag == "" {
+		return
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	rules := strings.Split(tag, ",")
+	for i, rule := range rules {
+		if rule == "dive" {
+			elemSchema, _ := s["items"].(jsonSchema)
+			if elemSchema == nil {
+				elemSchema, _ = s["additionalProperties"].(jsonSchema)
+			}
+			if elemSchema != nil {
+				applyValidateRules(elemSchema, t.Elem(), strings.Join(rules[i+1:], ","))
+			}
+			return
+		}
+		if strings.Contains(rule, "|") {
+			continue
+		}
+
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "min", "gte":
+			setBound(s, t, "min", param)
+		case "max", "lte":
+			setBound(s, t, "max", param)
+		case "len":
+			setBound(s, t, "min", param)
+			setBound(s, t, "max", param)
+		case "oneof":
+			if enum := oneOfValues(t, param); len(enum) > 0 {
+				s["enum"] = enum
+			}
+		}
+	}
+}
+
+func setBound(s jsonSchema, t reflect.Type, bound, param string) {
+	n, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return
+	}
+
+	var keyword string
+	switch t.Kind() {
+	case reflect.String:
+		keyword = bound + "Length"
+	case reflect.Slice, reflect.Array:
+		keyword = bound + "Items"
+	case reflect.Map:
+		keyword = bound + "Properties"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		keyword = bound + "imum"
+	default:
+		return
+	}
+	s[keyword] = n
+}
+
+// oneOfValues returns the values of a oneof param, as numbers unless t is a
+// string type.
+func oneOfValues(t reflect.Type, param string) []any {
+	raw := tsgencore.ValidateOneOfValues(param)
+	values := make([]any, 0, len(raw))
+	for _, v := range raw {
+		if t.Kind() == reflect.String {
+			values = append(values, v)
+		} else if n, err := strconv.ParseFloat(v, 64); err == nil {
+			values = append(values, n)
+		}
+	}
+	return values
+}
+
+// tsTypeToSchema returns the schema for a ts_type override. Primitives,
+// literals, and unions of them are translated; anything else is left
+// unconstrained, with the TypeScript type recorded in "x-ts-type".
+func tsTypeToSchema(tsType string) jsonSchema {
+	var options []jsonSchema
+	for part := range strings.SplitSeq(tsType, "|") {
+		s := tsPrimitiveToSchema(strings.TrimSpace(part))
+		if s == nil {
+			return jsonSchema{"x-ts-type": tsType}
+		}
+		options = append(options, s)
+	}
+
+	if len(options) == 1 {
+		return options[0]
+	}
+
+	enum := make([]any, 0, len(options))
+	for _, option := range options {
+		v, ok := option["const"]
+		if !ok {
+			anyOf := make([]any, len(options))
+			for i, o := range options {
+				anyOf[i] = o
+			}
+			return jsonSchema{"anyOf": anyOf}
+		}
+		enum = append(enum, v)
+	}
+	return jsonSchema{"enum": enum}
+}
+
+func tsPrimitiveToSchema(tsType string) jsonSchema {
+	switch tsType {
+	case "string", "number", "boolean", "null":
+		return jsonSchema{"type": tsType}
+	case "unknown", "any":
+		return jsonSchema{}
+	case "true", "false":
+		return jsonSchema{"const": tsType == "true"}
+	}
+
+	if len(tsType) >= 2 && (tsType[0] == '"' || tsType[0] == '\'') && tsType[len(tsType)-1] == tsType[0] {
+		return jsonSchema{"const": tsType[1 : len(tsType)-1]}
+	}
+	if n, err := strconv.ParseFloat(tsType, 64); err == nil {
+		return jsonSchema{"const": n}
+	}
+	return nil
+}
@@ -0,0 +1,320 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type openAPIAddress struct {
+	City string `json:"city" validate:"required,min=2"`
+}
+
+type OpenAPIBase struct {
+	ID int `json:"id"`
+}
+
+type openAPIKind string
+
+func (openAPIKind) TSEnumValues() []openAPIKind { return []openAPIKind{"draft", "final"} }
+
+type openAPIPriority int
+
+func (openAPIPriority) TSEnumValues() []openAPIPriority { return []openAPIPriority{1, 2, 3} }
+
+type openAPIInput struct {
+	OpenAPIBase
+	Name     string           `json:"name,omitempty" validate:"required,max=20"`
+	Role     string           `json:"role" validate:"oneof=admin 'power user' guest"`
+	Age      *int             `json:"age" validate:"min=18,max=130"`
+	Tags     []string         `json:"tags,omitempty" validate:"max=5,dive,min=1"`
+	Custom   string           `json:"custom" ts_type:"\"a\" | \"b\""`
+	Opaque   string           `json:"opaque" ts_type:"Array<Foo>"`
+	Home     openAPIAddress   `json:"home"`
+	Extra    *openAPIAddress  `json:"extra"`
+	Kind     openAPIKind      `json:"kind"`
+	Priority *openAPIPriority `json:"priority"`
+	Skipped  string           `json:"-"`
+}
+
+type openAPIOutput struct {
+	At   time.Time        `json:"at"`
+	Next *openAPIOutput   `json:"next,omitempty"`
+	Home openAPIAddress   `json:"home"`
+	Meta map[string]int64 `json:"meta"`
+}
+
+func decodeOpenAPIDoc(t *testing.T, opts OpenAPIOpts) map[string]any {
+	t.Helper()
+	content, err := GenerateOpenAPIContent(opts)
+	if err != nil {
+		t.Fatalf("GenerateOpenAPIContent failed: %s", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(content, &doc); err != nil {
+		t.Fatalf("failed to decode document: %s", err)
+	}
+	return doc
+}
+
+// dig walks a decoded JSON document along keys (object keys or array indices).
+func dig(t *testing.T, v any, keys ...any) any {
+	t.Helper()
+	for _, k := range keys {
+		switch k := k.(type) {
+		case string:
+			m, ok := v.(map[string]any)
+			if !ok {
+				t.Fatalf("dig %v: not an object at %q", keys, k)
+			}
+			v = m[k]
+		case int:
+			a, ok := v.([]any)
+			if !ok || k >= len(a) {
+				t.Fatalf("dig %v: no index %d", keys, k)
+			}
+			v = a[k]
+		}
+	}
+	return v
+}
+
+func TestGenerateOpenAPISchemas(t *testing.T) {
+	doc := decodeOpenAPIDoc(t, OpenAPIOpts{RouteDefs: []RouteDef{
+		Mutation("save", func(ctx context.Context, input openAPIInput) (openAPIOutput, error) {
+			return openAPIOutput{}, nil
+		}).WithErrors("TAKEN").Def(),
+	}})
+
+	if doc["openapi"] != "3.1.0" || dig(t, doc, "info", "title") != "API" {
+		t.Errorf("unexpected header: openapi %v, info %v", doc["openapi"], doc["info"])
+	}
+
+	schemas := dig(t, doc, "components", "schemas")
+	input := dig(t, schemas, "openAPIInput")
+	props := dig(t, input, "properties")
+
+	tests := []struct {
+		path []any
+		want any
+	}{
+		{[]any{"required"}, []any{"id", "name", "role", "custom", "opaque", "home", "kind"}},
+		{[]any{"properties", "id", "type"}, "integer"},
+		{[]any{"properties", "name", "maxLength"}, 20.0},
+		{[]any{"properties", "role", "enum"}, []any{"admin", "power user", "guest"}},
+		{[]any{"properties", "age", "minimum"}, 18.0},
+		{[]any{"properties", "age", "maximum"}, 130.0},
+		{[]any{"properties", "tags", "maxItems"}, 5.0},
+		{[]any{"properties", "tags", "items", "minLength"}, 1.0},
+		{[]any{"properties", "custom", "enum"}, []any{"a", "b"}},
+		{[]any{"properties", "opaque", "x-ts-type"}, "Array<Foo>"},
+		{[]any{"properties", "home", "$ref"}, "#/components/schemas/openAPIAddress"},
+		{[]any{"properties", "kind", "type"}, "string"},
+		{[]any{"properties", "kind", "enum"}, []any{"draft", "final"}},
+		{[]any{"properties", "priority", "type"}, "integer"},
+		{[]any{"properties", "priority", "enum"}, []any{1.0, 2.0, 3.0}},
+	}
+	for _, tt := range tests {
+		if got := dig(t, input, tt.path...); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("openAPIInput %v = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	if _, ok := props.(map[string]any)["Skipped"]; ok {
+		t.Error(`fields tagged json:"-" should be skipped`)
+	}
+
+	if got := dig(t, schemas, "openAPIAddress", "properties", "city", "minLength"); got != 2.0 {
+		t.Errorf("openAPIAddress city minLength = %v, want 2", got)
+	}
+	if got := dig(t, schemas, "openAPIOutput", "properties", "next", "$ref"); got != "#/components/schemas/openAPIOutput" {
+		t.Errorf("recursive field $ref = %v", got)
+	}
+	if got := dig(t, schemas, "openAPIOutput", "properties", "at", "format"); got != "date-time" {
+		t.Errorf("time.Time format = %v, want date-time", got)
+	}
+	if got := dig(t, schemas, "openAPIOutput", "properties", "meta", "additionalProperties", "type"); got != "integer" {
+		t.Errorf("map value type = %v, want integer", got)
+	}
+
+	op := dig(t, doc, "paths", "/api/save", "post")
+	if got := dig(t, op, "requestBody", "content", "application/json", "schema", "$ref"); got != "#/components/schemas/openAPIInput" {
+		t.Errorf("request body $ref = %v", got)
+	}
+	errSchema := dig(t, op, "responses", "default", "content", "application/json", "schema")
+	codes := dig(t, errSchema, "properties", "code", "enum").([]any)
+	if len(codes) != len(builtinErrorCodes)+1 || codes[len(codes)-1] != "TAKEN" {
+		t.Errorf("error codes = %v, want the built-in codes and TAKEN", codes)
+	}
+	if got := dig(t, errSchema, "if", "properties", "code", "const"); got != string(ErrorCodeValidation) {
+		t.Errorf("error schema condition = %v, want code %s", got, ErrorCodeValidation)
+	}
+	details := dig(t, errSchema, "then", "properties", "details")
+	if dig(t, details, "type") != "array" || dig(t, details, "items", "$ref") != "#/components/schemas/"+fieldErrorSchemaName {
+		t.Errorf("validation error details = %v, want an array of %s", details, fieldErrorSchemaName)
+	}
+	if _, ok := dig(t, schemas, fieldErrorSchemaName, "properties").(map[string]any)["path"]; !ok {
+		t.Errorf("missing %s component", fieldErrorSchemaName)
+	}
+}
+
+func TestGenerateOpenAPIQueryParams(t *testing.T) {
+	doc := decodeOpenAPIDoc(t, OpenAPIOpts{BasePath: "rpc", RouteDefs: []RouteDef{
+		Query("find", func(ctx context.Context, input openAPIInput) (bool, error) { return true, nil }).Def(),
+		Subscription("watch", func(ctx context.Context, input struct{}, stream *Stream[greetOutput]) error { return nil }).Def(),
+	}})
+
+	params := map[string]map[string]any{}
+	for _, p := range dig(t, doc, "paths", "/rpc/find", "get", "parameters").([]any) {
+		params[p.(map[string]any)["name"].(string)] = p.(map[string]any)
+	}
+
+	for name, required := range map[string]bool{"id": true, "name": true, "tags": false, "home.city": true, "extra.city": false} {
+		p, ok := params[name]
+		if !ok {
+			t.Errorf("missing query param %q", name)
+			continue
+		}
+		if p["in"] != "query" || p["required"] != required {
+			t.Errorf("param %q = %v, want in query, required %v", name, p, required)
+		}
+	}
+	if params["tags"]["explode"] != true {
+		t.Error("array params should be exploded, as repeated keys")
+	}
+
+	watch := dig(t, doc, "paths", "/rpc/watch", "get")
+	if _, ok := watch.(map[string]any)["parameters"]; ok {
+		t.Error("an empty input should have no parameters")
+	}
+	content := dig(t, watch, "responses", "200", "content").(map[string]any)
+	if _, ok := content[contentTypeSSE]; !ok {
+		t.Errorf("subscription content = %v, want %s", content, contentTypeSSE)
+	}
+	if got := dig(t, content, contentTypeNDJSON, "schema", "properties", "data", "$ref"); got != "#/components/schemas/greetOutput" {
+		t.Errorf("subscription message $ref = %v", got)
+	}
+}
+
+func TestGenerateOpenAPI(t *testing.T) {
+	outPath := filepath.Join(t.TempDir(), "nested", "openapi.json")
+
+	s := newTestServer(nil)
+	if err := GenerateOpenAPI(OpenAPIOpts{OutPath: outPath, RouteDefs: s.RouteDefs(), Title: "Test", Version: "1.2.3"}); err != nil {
+		t.Fatalf("GenerateOpenAPI failed: %s", err)
+	}
+
+	content, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("Failed to read generated document: %s", err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(content, &doc); err != nil {
+		t.Fatalf("failed to decode document: %s", err)
+	}
+	if dig(t, doc, "info", "version") != "1.2.3" {
+		t.Errorf("info = %v", doc["info"])
+	}
+	for _, path := range []string{"/api/greet", "/api/rename", "/api/ping"} {
+		if _, ok := dig(t, doc, "paths").(map[string]any)[path]; !ok {
+			t.Errorf("missing path %s", path)
+		}
+	}
+
+	if err := GenerateOpenAPI(OpenAPIOpts{}); err == nil {
+		t.Error("GenerateOpenAPI should require an out path")
+	}
+}
+
+func TestGenerateOpenAPITypeOverrides(t *testing.T) {
+	type nullString struct {
+		String string
+		Valid  bool
+	}
+	type input struct {
+		Nickname nullString `json:"nickname"`
+	}
+
+	doc := decodeOpenAPIDoc(t, OpenAPIOpts{
+		RouteDefs: []RouteDef{
+			Query("find", func(ctx context.Context, in input) (nullString, error) { return nullString{}, nil }).Def(),
+		},
+		TypeOverrides: map[reflect.Type]string{reflect.TypeFor[nullString](): "string | null"},
+	})
+
+	op := dig(t, doc, "paths", "/api/find", "get")
+	if got := dig(t, op, "parameters", 0, "name"); got != "nickname" {
+		t.Errorf("param name = %v, want nickname (not flattened)", got)
+	}
+	want := []any{map[string]any{"type": "string"}, map[string]any{"type": "null"}}
+	if got := dig(t, op, "responses", "200", "content", "application/json", "schema", "anyOf"); !reflect.DeepEqual(got, want) {
+		t.Errorf("output schema anyOf = %v, want %v", got, want)
+	}
+}
+
+type openAPIRaw struct{ v any }
+
+func (r openAPIRaw) MarshalJSON() ([]byte, error) { return json.Marshal(r.v) }
+
+type openAPIOverriddenRaw struct{ v any }
+
+func (r openAPIOverriddenRaw) MarshalJSON() ([]byte, error) { return json.Marshal(r.v) }
+
+func TestGenerateOpenAPIMarshalers(t *testing.T) {
+	type input struct {
+		Addr netip.Addr `json:"addr"`
+		Data []byte     `json:"data" validate:"max=16"`
+	}
+	type output struct {
+		Addr       *netip.Addr          `json:"addr"`
+		Data       []byte               `json:"data"`
+		Raw        openAPIRaw           `json:"raw"`
+		Overridden openAPIOverriddenRaw `json:"overridden"`
+	}
+
+	doc := decodeOpenAPIDoc(t, OpenAPIOpts{
+		RouteDefs: []RouteDef{
+			Query("find", func(ctx context.Context, in input) (output, error) { return output{}, nil }).Def(),
+		},
+		TypeOverrides: map[reflect.Type]string{reflect.TypeFor[openAPIOverriddenRaw](): "number"},
+	})
+
+	ref := dig(t, doc, "paths", "/api/find", "get", "responses", "200", "content", "application/json", "schema", "$ref").(string)
+	props := dig(t, doc, "components", "schemas", strings.TrimPrefix(ref, "#/components/schemas/"), "properties")
+	tests := []struct {
+		field string
+		want  any
+	}{
+		{"addr", map[string]any{"type": "string"}},
+		{"data", map[string]any{"type": "string", "contentEncoding": "base64"}},
+		{"raw", map[string]any{}},
+		{"overridden", map[string]any{"type": "number"}},
+	}
+	for _, tt := range tests {
+		if got := dig(t, props, tt.field); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("output %s schema = %v, want %v", tt.field, got, tt.want)
+		}
+	}
+
+	params := dig(t, doc, "paths", "/api/find", "get", "parameters").([]any)
+	if len(params) != 2 {
+		t.Fatalf("got %d query params, want addr and data", len(params))
+	}
+	for i, name := range []string{"addr", "data"} {
+		p := params[i].(map[string]any)
+		if p["name"] != name || dig(t, p, "schema", "type") != "string" {
+			t.Errorf("param %d = %v, want %s as a string", i, p, name)
+		}
+		if _, ok := p["explode"]; ok {
+			t.Errorf("param %s should not be exploded", name)
+		}
+	}
+	if _, ok := dig(t, params[1], "schema").(map[string]any)["maxItems"]; ok {
+		t.Error("validate rules should not apply to a byte slice's schema")
+	}
+}
@@ -1,6 +1,7 @@
 package rpc
 
 import (
+	"reflect"
 	"strings"
 	"text/template"
 
@@ -14,13 +15,15 @@ type RouteDef = struct {
 	ActionType ActionType
 	Input      any
 	Output     any
+	Errors     []ErrorCode // Optional. Error codes the route may return, beyond the built-in ones.
 }
 
 type ActionType = string
 
 const (
-	ActionTypeQuery    ActionType = "query"
-	ActionTypeMutation ActionType = "mutation"
+	ActionTypeQuery        ActionType = "query"
+	ActionTypeMutation     ActionType = "mutation"
+	ActionTypeSubscription ActionType = "subscription"
 )
 
 type AdHocType = tsgen.AdHocType
@@ -32,14 +35,25 @@ type Opts struct {
 	AdHocTypes        []*AdHocType
 	ExportRoutesArray bool
 	ExtraTSCode       string
+
+	// Optional. If set, a typed fetch client for the routes (with query and
+	// mutate functions) is emitted as well. See ClientOpts.
+	Client *ClientOpts
+
+	// Optional. See tsgen.Opts.TypeOverrides.
+	TypeOverrides map[reflect.Type]string
 }
 
 func GenerateTypeScript(opts Opts) error {
 	var items []tsgen.CollectionItem
 
 	for _, r := range opts.RouteDefs {
+		properties := map[string]any{"key": r.Key, "actionType": r.ActionType}
+		if len(r.Errors) > 0 {
+			properties["errorCodes"] = r.Errors
+		}
 		items = append(items, tsgen.CollectionItem{
-			ArbitraryProperties: map[string]any{"key": r.Key, "actionType": r.ActionType},
+			ArbitraryProperties: properties,
 			PhantomTypes: map[string]AdHocType{
 				"phantomInputType":  {TypeInstance: r.Input},
 				"phantomOutputType": {TypeInstance: r.Output},
@@ -49,7 +63,10 @@ func GenerateTypeScript(opts Opts) error {
 
 	var extraTSToUse string
 	if len(opts.RouteDefs) > 0 {
-		extraTSToUse = extraTSCode
+		extraTSToUse = errorTSCode + "\n\n" + extraTSCode
+		if opts.Client != nil {
+			extraTSToUse += "\n" + buildClientTS(opts.Client) + "\n"
+		}
 	}
 	if opts.ExtraTSCode != "" {
 		extraTSToUse += "\n" + opts.ExtraTSCode
@@ -62,6 +79,7 @@ func GenerateTypeScript(opts Opts) error {
 		ExtraTSCode:           extraTSToUse,
 		CollectionVarName:     CollectionVarName,
 		ExportCollectionArray: opts.ExportRoutesArray,
+		TypeOverrides:         opts.TypeOverrides,
 	})
 }
 
@@ -81,6 +99,7 @@ var extraTSCode = BuildFromCategories(
 			KeyUnionTypeName:     "QueryAPIKey",
 			InputUnionTypeName:   "QueryAPIInput",
 			OutputUnionTypeName:  "QueryAPIOutput",
+			ErrorUnionTypeName:   "QueryAPIError",
 		},
 		{
 			BaseOptions:          baseOptions,
@@ -90,6 +109,17 @@ var extraTSCode = BuildFromCategories(
 			KeyUnionTypeName:     "MutationAPIKey",
 			InputUnionTypeName:   "MutationAPIInput",
 			OutputUnionTypeName:  "MutationAPIOutput",
+			ErrorUnionTypeName:   "MutationAPIError",
+		},
+		{
+			BaseOptions:          baseOptions,
+			CategoryValue:        ActionTypeSubscription,
+			ItemTypeNameSingular: "SubscriptionAPIRoute",
+			ItemTypeNamePlural:   "SubscriptionAPIRoutes",
+			KeyUnionTypeName:     "SubscriptionAPIKey",
+			InputUnionTypeName:   "SubscriptionAPIInput",
+			OutputUnionTypeName:  "SubscriptionAPIOutput",
+			ErrorUnionTypeName:   "SubscriptionAPIError",
 		},
 	},
 )
@@ -110,6 +140,11 @@ type CategorySpecificOptions struct {
 	OutputUnionTypeName  string
 	SkipInput            bool
 	SkipOutput           bool
+
+	// Optional. If set, a type with this name is emitted that resolves to the
+	// union of errors (see RPCErrorUnion in the generated code) that the
+	// route may return, based on its "errorCodes" property.
+	ErrorUnionTypeName string
 }
 
 func BuildFromCategories(categories []CategorySpecificOptions) string {
@@ -138,6 +173,14 @@ func BuildFromCategories(categories []CategorySpecificOptions) string {
 			extraTSBuilder.WriteString("\n")
 		}
 
+		// ERROR
+		if c.ErrorUnionTypeName != "" {
+			if err := errorTmpl.Execute(&extraTSBuilder, c); err != nil {
+				panic(err)
+			}
+			extraTSBuilder.WriteString("\n")
+		}
+
 		if i < len(categories)-1 {
 			extraTSBuilder.WriteString("\n")
 		}
@@ -154,6 +197,7 @@ var (
 	baseTmpl   = template.Must(template.New("extraTS_1").Parse(baseTmplStr))
 	inputTmpl  = template.Must(template.New("extraTS_2").Parse(inputTmplStr))
 	outputTmpl = template.Must(template.New("extraTS_3").Parse(outputTmplStr))
+	errorTmpl  = template.Must(template.New("extraTS_4").Parse(errorTmplStr))
 )
 
 const (
@@ -164,4 +208,14 @@ export type {{ .KeyUnionTypeName }} = {{ .ItemTypeNameSingular }}["{{ .Discrimin
 	inputTmplStr = `export type {{ .InputUnionTypeName }}<T extends {{ .KeyUnionTypeName }}> = Extract<{{ .ItemTypeNameSingular }}, { {{ .DiscriminatorStr }}: T }>["phantomInputType"];`
 
 	outputTmplStr = `export type {{ .OutputUnionTypeName }}<T extends {{ .KeyUnionTypeName }}> = Extract<{{ .ItemTypeNameSingular }}, { {{ .DiscriminatorStr }}: T }>["phantomOutputType"];`
+
+	errorTmplStr = `export type {{ .ErrorUnionTypeName }}<T extends {{ .KeyUnionTypeName }}> = RPCErrorUnion<Extract<{{ .ItemTypeNameSingular }}, { {{ .DiscriminatorStr }}: T }> extends { errorCodes: readonly (infer C extends string)[] } ? C : never>;`
 )
+
+// errorTSCode mirrors Error and FieldError. Every route's error union
+// includes the built-in error codes.
+var errorTSCode = `export type RPCBuiltinErrorCode = "` + strings.Join(builtinErrorCodes, `" | "`) + `";
+export type RPCFieldError = { path: string; tag: string; param?: string; message: string };
+export type RPCErrorUnion<C extends string> = {
+	[K in C | RPCBuiltinErrorCode]: { code: K; message: string; details?: K extends "` + ErrorCodeValidation + `" ? RPCFieldError[] : unknown };
+}[C | RPCBuiltinErrorCode];`
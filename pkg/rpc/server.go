@@ -0,0 +1,306 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/sjc5/kit/pkg/response"
+	"github.com/sjc5/kit/pkg/validate"
+)
+
+// HandlerFunc handles a single RPC route. Input has already been decoded and
+// validated by the time it is called.
+type HandlerFunc[I, O any] = func(ctx context.Context, input I) (O, error)
+
+// Route is a route definition paired with its handler. Create one with Query
+// or Mutation, and serve it by registering it with a Server.
+type Route struct {
+	def   RouteDef
+	serve func(s *Server, w http.ResponseWriter, r *http.Request)
+
+	// callJSON runs the handler with input decoded from JSON, and returns the
+	// JSON-encoded output. It is nil for routes that can't be batched.
+	callJSON func(s *Server, r *http.Request, rawInput []byte) ([]byte, *Error)
+}
+
+// Def returns the route definition derived from the route's handler, suitable
+// for passing to GenerateTypeScript.
+func (rt *Route) Def() RouteDef {
+	return rt.def
+}
+
+// WithErrors declares the error codes (beyond the built-in ones) that the
+// route's handler may return as an *Error, so that GenerateTypeScript can
+// emit them as part of the route's error union. It returns the route.
+func (rt *Route) WithErrors(codes ...ErrorCode) *Route {
+	for _, code := range codes {
+		if !slices.Contains(rt.def.Errors, code) {
+			rt.def.Errors = append(rt.def.Errors, code)
+		}
+	}
+	return rt
+}
+
+// Query returns a query route. Queries are served for GET requests, with
+// input decoded from the URL search params (see validate.URLSearchParamsInto).
+// I must be a struct type.
+func Query[I, O any](key string, fn HandlerFunc[I, O]) *Route {
+	return newRoute(key, ActionTypeQuery, fn)
+}
+
+// Mutation returns a mutation route. Mutations are served for POST requests,
+// with input decoded from the JSON request body. An empty body is treated as
+// the zero value of I. I must be a struct type.
+func Mutation[I, O any](key string, fn HandlerFunc[I, O]) *Route {
+	return newRoute(key, ActionTypeMutation, fn)
+}
+
+func newRoute[I, O any](key string, actionType ActionType, fn HandlerFunc[I, O]) *Route {
+	mustBeValidRoute[I](key, fn != nil)
+
+	var input I
+	var output O
+
+	return &Route{
+		def: RouteDef{Key: key, ActionType: actionType, Input: input, Output: output},
+		serve: func(s *Server, w http.ResponseWriter, r *http.Request) {
+			input, err := decodeInput[I](s.validate, actionType, r)
+			if err != nil {
+				writeError(w, inputError(err, reflect.TypeFor[I]()))
+				return
+			}
+
+			output, err := fn(context.WithValue(r.Context(), requestCtxKey{}, r), input)
+			if err != nil {
+				writeError(w, s.handlerError(r, key, err))
+				return
+			}
+
+			res := response.New(w)
+			res.JSON(output)
+		},
+		callJSON: func(s *Server, r *http.Request, rawInput []byte) ([]byte, *Error) {
+			if len(rawInput) == 0 {
+				rawInput = []byte("null")
+			}
+			var input I
+			if err := s.validate.JSONBytesInto(rawInput, &input); err != nil {
+				return nil, inputError(err, reflect.TypeFor[I]())
+			}
+
+			output, err := fn(context.WithValue(r.Context(), requestCtxKey{}, r), input)
+			if err != nil {
+				return nil, s.handlerError(r, key, err)
+			}
+
+			data, err := json.Marshal(output)
+			if err != nil {
+				return nil, s.handlerError(r, key, err)
+			}
+			return data, nil
+		},
+	}
+}
+
+// mustBeValidRoute panics if a route's key, handler, or input type is invalid.
+func mustBeValidRoute[I any](key string, hasHandler bool) {
+	if key == "" {
+		panic("rpc: route key must not be empty")
+	}
+	if !hasHandler {
+		panic("rpc: handler must not be nil for route " + key)
+	}
+	if t := reflect.TypeFor[I](); t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("rpc: input type for route %s must be a struct, got %s", key, t))
+	}
+}
+
+func decodeInput[I any](v *validate.Validate, actionType ActionType, r *http.Request) (I, error) {
+	var input I
+
+	if actionType != ActionTypeMutation {
+		return input, v.URLSearchParamsInto(r, &input)
+	}
+
+	err := v.JSONBodyInto(r.Body, &input)
+	if errors.Is(err, io.EOF) {
+		if err := v.Instance.Struct(&input); err != nil {
+			return input, fmt.Errorf(validate.ValidationErrorPrefix+"%w", err)
+		}
+		return input, nil
+	}
+	return input, err
+}
+
+type requestCtxKey struct{}
+
+// RequestFromContext returns the HTTP request being served, given the context
+// passed to a route handler. It returns nil for any other context.
+func RequestFromContext(ctx context.Context) *http.Request {
+	r, _ := ctx.Value(requestCtxKey{}).(*http.Request)
+	return r
+}
+
+const defaultBasePath = "/api/"
+
+type ServerOpts struct {
+	// Optional. Defaults to "/api/". Each route is served at BasePath + key.
+	BasePath string
+
+	// Optional. Defaults to validate.New().
+	Validate *validate.Validate
+
+	// Optional. Called with every error returned by a route handler that
+	// isn't an *Error (e.g., for logging). The client only ever sees a generic
+	// ErrorCodeInternal error for these.
+	OnError func(r *http.Request, err error)
+
+	// Optional. Defaults to false. Set to true to not serve the batch
+	// endpoint (see BatchKey).
+	DisableBatch bool
+
+	// Optional. Defaults to 8. The maximum number of items of a single batch
+	// request that are handled concurrently. Batches containing a mutation
+	// are always handled one item at a time (see BatchKey).
+	BatchConcurrency int
+
+	// Optional. Defaults to 32. Batch requests with more items are rejected.
+	MaxBatchSize int
+}
+
+// Server is an http.Handler that serves a set of routes. Because the route
+// definitions are derived from the handlers themselves, passing RouteDefs()
+// to GenerateTypeScript keeps the client in sync with the server.
+type Server struct {
+	routes   map[string]*Route // key -> route
+	ordered  []*Route          // in registration order
+	basePath string
+	validate *validate.Validate
+	onError  func(r *http.Request, err error)
+
+	batchEnabled     bool
+	batchConcurrency int
+	maxBatchSize     int
+}
+
+func NewServer(opts *ServerOpts) *Server {
+	if opts == nil {
+		opts = new(ServerOpts)
+	}
+
+	s := &Server{
+		routes:   make(map[string]*Route),
+		basePath: opts.BasePath,
+		validate: opts.Validate,
+		onError:  opts.OnError,
+
+		batchEnabled:     !opts.DisableBatch,
+		batchConcurrency: opts.BatchConcurrency,
+		maxBatchSize:     opts.MaxBatchSize,
+	}
+
+	if s.basePath == "" {
+		s.basePath = defaultBasePath
+	}
+	if !strings.HasPrefix(s.basePath, "/") {
+		s.basePath = "/" + s.basePath
+	}
+	if !strings.HasSuffix(s.basePath, "/") {
+		s.basePath += "/"
+	}
+	if s.validate == nil {
+		s.validate = validate.New()
+	}
+	if s.batchConcurrency <= 0 {
+		s.batchConcurrency = defaultBatchConcurrency
+	}
+	if s.maxBatchSize <= 0 {
+		s.maxBatchSize = defaultMaxBatchSize
+	}
+
+	return s
+}
+
+// Register adds routes to the server. It panics if a route with the same key
+// is already registered, or if a route uses the reserved BatchKey. Register
+// must not be called concurrently with ServeHTTP.
+func (s *Server) Register(routes ...*Route) {
+	for _, rt := range routes {
+		if rt.def.Key == BatchKey {
+			panic("rpc: route key " + BatchKey + " is reserved for batch requests")
+		}
+		if _, exists := s.routes[rt.def.Key]; exists {
+			panic("rpc: route already registered for key " + rt.def.Key)
+		}
+		s.routes[rt.def.Key] = rt
+		s.ordered = append(s.ordered, rt)
+	}
+}
+
+// RouteDefs returns the definitions of every registered route, in
+// registration order.
+func (s *Server) RouteDefs() []RouteDef {
+	defs := make([]RouteDef, 0, len(s.ordered))
+	for _, rt := range s.ordered {
+		defs = append(defs, rt.def)
+	}
+	return defs
+}
+
+// BasePath returns the path prefix under which routes are served.
+func (s *Server) BasePath() string {
+	return s.basePath
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key, ok := strings.CutPrefix(r.URL.Path, s.basePath)
+	if ok && key == BatchKey && s.batchEnabled {
+		s.serveBatch(w, r)
+		return
+	}
+
+	var rt *Route
+	if ok {
+		rt = s.routes[key]
+	}
+	if rt == nil {
+		writeError(w, NewError(ErrorCodeNotFound, "no route for key "+key))
+		return
+	}
+
+	method := methodForActionType(rt.def.ActionType)
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeError(w, NewError(ErrorCodeMethodNotAllowed, "route "+key+" requires "+method))
+		return
+	}
+
+	rt.serve(s, w, r)
+}
+
+// handlerError returns the error to send to the client for an error returned
+// by a route handler.
+func (s *Server) handlerError(r *http.Request, key string, err error) *Error {
+	var rpcErr *Error
+	if errors.As(err, &rpcErr) {
+		return rpcErr
+	}
+	if s.onError != nil {
+		s.onError(r, fmt.Errorf("rpc: handler for route %s failed: %w", key, err))
+	}
+	return NewError(ErrorCodeInternal, "internal server error")
+}
+
+func methodForActionType(actionType ActionType) string {
+	if actionType == ActionTypeMutation {
+		return http.MethodPost
+	}
+	return http.MethodGet
+}
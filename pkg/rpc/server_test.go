@@ -0,0 +1,210 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type greetInput struct {
+	Name  string `json:"name" validate:"required"`
+	Times int    `json:"times"`
+}
+
+type greetOutput struct {
+	Greeting string `json:"greeting"`
+}
+
+type renameInput struct {
+	ID   int    `json:"id" validate:"required"`
+	Name string `json:"name" validate:"required"`
+}
+
+func newTestServer(onError func(r *http.Request, err error)) *Server {
+	s := NewServer(&ServerOpts{OnError: onError})
+	s.Register(
+		Query("greet", func(ctx context.Context, input greetInput) (greetOutput, error) {
+			return greetOutput{Greeting: strings.Repeat("hi "+input.Name+" ", input.Times)}, nil
+		}),
+		Mutation("rename", func(ctx context.Context, input renameInput) (bool, error) {
+			if input.ID == 13 {
+				return false, errors.New("unlucky")
+			}
+			return true, nil
+		}),
+		Mutation("ping", func(ctx context.Context, input struct{}) (string, error) {
+			if RequestFromContext(ctx) == nil {
+				return "", errors.New("request missing from context")
+			}
+			return "pong", nil
+		}),
+	)
+	return s
+}
+
+func do(s http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServerQuery(t *testing.T) {
+	s := newTestServer(nil)
+
+	rec := do(s, http.MethodGet, "/api/greet?name=Bob&times=2", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body: %s)", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+
+	var out greetOutput
+	if err := json.NewDecoder(rec.Body).Decode(&out); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if out.Greeting != "hi Bob hi Bob " {
+		t.Errorf("Greeting = %q", out.Greeting)
+	}
+
+	if rec := do(s, http.MethodGet, "/api/greet", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing required input: status = %d, want 400", rec.Code)
+	}
+
+	type searchInput struct {
+		Q    string `json:"q,omitempty"`
+		Page int    `json:"page"`
+	}
+	search := NewServer(nil)
+	search.Register(Query("search", func(ctx context.Context, input searchInput) (searchInput, error) {
+		return input, nil
+	}))
+	rec = do(search, http.MethodGet, "/api/search?q=hello&page=2", "")
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"q":"hello","page":2}` {
+		t.Errorf("omitempty query field: body = %s, want q and page", got)
+	}
+}
+
+func TestServerMutation(t *testing.T) {
+	var loggedErr error
+	s := newTestServer(func(r *http.Request, err error) { loggedErr = err })
+
+	tests := []struct {
+		name     string
+		key      string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{"ok", "rename", `{"id":1,"name":"x"}`, http.StatusOK, "true"},
+		{"invalid", "rename", `{"id":1}`, http.StatusBadRequest, ""},
+		{"malformed", "rename", `{"id":`, http.StatusBadRequest, ""},
+		{"empty body validates zero value", "rename", "", http.StatusBadRequest, ""},
+		{"empty body", "ping", "", http.StatusOK, `"pong"`},
+		{"handler error", "rename", `{"id":13,"name":"x"}`, http.StatusInternalServerError, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := do(s, http.MethodPost, "/api/"+tt.key, tt.body)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantCode, rec.Body)
+			}
+			if tt.wantBody != "" && strings.TrimSpace(rec.Body.String()) != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body, tt.wantBody)
+			}
+		})
+	}
+
+	if loggedErr == nil || !strings.Contains(loggedErr.Error(), "unlucky") {
+		t.Errorf("OnError got %v, want the handler error", loggedErr)
+	}
+	if rec := do(s, http.MethodPost, "/api/rename", `{"id":13,"name":"x"}`); strings.Contains(rec.Body.String(), "unlucky") {
+		t.Error("handler error message leaked to the client")
+	}
+}
+
+func TestServerRouting(t *testing.T) {
+	s := newTestServer(nil)
+
+	if rec := do(s, http.MethodGet, "/api/nope", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown key: status = %d, want 404", rec.Code)
+	}
+	if rec := do(s, http.MethodGet, "/other/greet?name=x", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("outside base path: status = %d, want 404", rec.Code)
+	}
+
+	rec := do(s, http.MethodPost, "/api/greet", "")
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != http.MethodGet {
+		t.Errorf("wrong method: status = %d, Allow = %q, want 405 and GET", rec.Code, rec.Header().Get("Allow"))
+	}
+
+	custom := NewServer(&ServerOpts{BasePath: "rpc"})
+	custom.Register(Query("greet", func(ctx context.Context, input greetInput) (greetOutput, error) {
+		return greetOutput{}, nil
+	}))
+	if rec := do(custom, http.MethodGet, "/rpc/greet?name=x", ""); rec.Code != http.StatusOK {
+		t.Errorf("custom base path: status = %d, want 200", rec.Code)
+	}
+}
+
+func TestServerRouteDefs(t *testing.T) {
+	s := newTestServer(nil)
+
+	defs := s.RouteDefs()
+	if len(defs) != 3 {
+		t.Fatalf("got %d route defs, want 3", len(defs))
+	}
+	if defs[0].Key != "greet" || defs[0].ActionType != ActionTypeQuery {
+		t.Errorf("defs[0] = %+v, want the greet query", defs[0])
+	}
+	if _, ok := defs[0].Input.(greetInput); !ok {
+		t.Errorf("defs[0].Input has type %T, want greetInput", defs[0].Input)
+	}
+	if defs[1].Key != "rename" || defs[1].ActionType != ActionTypeMutation {
+		t.Errorf("defs[1] = %+v, want the rename mutation", defs[1])
+	}
+
+	err := GenerateTypeScript(Opts{
+		OutPath:   filepath.Join(t.TempDir(), testFileName),
+		RouteDefs: defs,
+	})
+	if err != nil {
+		t.Fatalf("GenerateTypeScript failed: %s", err)
+	}
+}
+
+func TestRegisterPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{"duplicate key", func() {
+			s := newTestServer(nil)
+			s.Register(Query("greet", func(ctx context.Context, input greetInput) (int, error) { return 0, nil }))
+		}},
+		{"non-struct input", func() {
+			Query("bad", func(ctx context.Context, input string) (int, error) { return 0, nil })
+		}},
+		{"empty key", func() {
+			Mutation("", func(ctx context.Context, input struct{}) (int, error) { return 0, nil })
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
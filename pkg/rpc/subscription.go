@@ -0,0 +1,187 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// SubscriptionHandlerFunc handles a subscription. It pushes messages with
+// stream.Send until it is done (or ctx is canceled, e.g. because the client
+// disconnected), then returns.
+type SubscriptionHandlerFunc[I, O any] = func(ctx context.Context, input I, stream *Stream[O]) error
+
+// Subscription returns a subscription route. Like queries, subscriptions are
+// served for GET requests, with input decoded from the URL search params.
+// Each message is streamed to the client as soon as it is sent, either as
+// Server-Sent Events (if the request's Accept header includes
+// "text/event-stream", as it does for EventSource) or otherwise as
+// newline-delimited JSON. I must be a struct type.
+//
+// If the handler returns an error before sending any message, the client
+// gets a regular error response. After that, the error is sent in-band: as an
+// "error" event for SSE, or as a {"error": ...} line for NDJSON. For SSE, a
+// final "end" event is sent when the handler returns without error, so that
+// EventSource clients know not to reconnect.
+func Subscription[I, O any](key string, fn SubscriptionHandlerFunc[I, O]) *Route {
+	mustBeValidRoute[I](key, fn != nil)
+
+	var input I
+	var output O
+
+	return &Route{
+		def: RouteDef{Key: key, ActionType: ActionTypeSubscription, Input: input, Output: output},
+		serve: func(s *Server, w http.ResponseWriter, r *http.Request) {
+			input, err := decodeInput[I](s.validate, ActionTypeSubscription, r)
+			if err != nil {
+				writeError(w, inputError(err, reflect.TypeFor[I]()))
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), requestCtxKey{}, r)
+			stream := &Stream[O]{sw: newStreamWriter(ctx, w, r)}
+
+			err = fn(ctx, input, stream)
+
+			stream.sw.mu.Lock()
+			defer stream.sw.mu.Unlock()
+			stream.sw.closed = true
+
+			if err != nil {
+				rpcErr := s.handlerError(r, key, err)
+				if !stream.sw.started {
+					writeError(w, rpcErr)
+					return
+				}
+				payload, _ := json.Marshal(rpcErr)
+				stream.sw.writeFrame(frameError, payload)
+				return
+			}
+
+			if !stream.sw.started {
+				stream.sw.start()
+			}
+			if stream.sw.sse {
+				stream.sw.writeFrame(frameEnd, []byte("null"))
+			}
+		},
+	}
+}
+
+// Stream pushes messages of type O to a subscriber. It is safe for
+// concurrent use.
+type Stream[O any] struct {
+	sw *streamWriter
+}
+
+// Send writes a message to the client and flushes it. It returns an error if
+// the context has been canceled, the write fails, or the handler has already
+// returned. After an error, the handler should return.
+func (s *Stream[O]) Send(msg O) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	s.sw.mu.Lock()
+	defer s.sw.mu.Unlock()
+
+	if s.sw.closed {
+		return ErrStreamClosed
+	}
+	if err := s.sw.ctx.Err(); err != nil {
+		return err
+	}
+	if !s.sw.started {
+		s.sw.start()
+	}
+	return s.sw.writeFrame(frameData, payload)
+}
+
+// ErrStreamClosed is returned by Stream.Send once the subscription handler
+// has returned.
+var ErrStreamClosed = errors.New("rpc: stream closed")
+
+type frameKind uint8
+
+const (
+	frameData frameKind = iota
+	frameError
+	frameEnd
+)
+
+const (
+	contentTypeSSE    = "text/event-stream"
+	contentTypeNDJSON = "application/x-ndjson"
+)
+
+type streamWriter struct {
+	mu      sync.Mutex
+	ctx     context.Context
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	sse     bool
+	started bool // response headers have been written
+	closed  bool // the handler has returned
+}
+
+func newStreamWriter(ctx context.Context, w http.ResponseWriter, r *http.Request) *streamWriter {
+	return &streamWriter{
+		ctx: ctx,
+		w:   w,
+		rc:  http.NewResponseController(w),
+		sse: strings.Contains(r.Header.Get("Accept"), contentTypeSSE),
+	}
+}
+
+func (sw *streamWriter) start() {
+	h := sw.w.Header()
+	if sw.sse {
+		h.Set("Content-Type", contentTypeSSE)
+	} else {
+		h.Set("Content-Type", contentTypeNDJSON)
+	}
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	sw.w.WriteHeader(http.StatusOK)
+	sw.started = true
+}
+
+func (sw *streamWriter) writeFrame(kind frameKind, payload []byte) error {
+	var sb strings.Builder
+
+	if sw.sse {
+		switch kind {
+		case frameError:
+			sb.WriteString("event: error\n")
+		case frameEnd:
+			sb.WriteString("event: end\n")
+		}
+		sb.WriteString("data: ")
+		sb.Write(payload)
+		sb.WriteString("\n\n")
+	} else {
+		switch kind {
+		case frameData:
+			sb.WriteString(`{"data":`)
+		case frameError:
+			sb.WriteString(`{"error":`)
+		default:
+			return nil
+		}
+		sb.Write(payload)
+		sb.WriteString("}\n")
+	}
+
+	if _, err := sw.w.Write([]byte(sb.String())); err != nil {
+		return err
+	}
+	if err := sw.rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
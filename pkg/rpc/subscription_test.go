@@ -0,0 +1,164 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type tickInput struct {
+	Count  int `json:"count" validate:"max=10"`
+	FailAt int `json:"failAt"`
+}
+
+type tick struct {
+	N int `json:"n"`
+}
+
+func newSubscriptionServer(leaked **Stream[tick]) *Server {
+	s := NewServer(nil)
+	s.Register(Subscription("ticks", func(ctx context.Context, input tickInput, stream *Stream[tick]) error {
+		if leaked != nil {
+			*leaked = stream
+		}
+		for i := 1; i <= input.Count; i++ {
+			if i == input.FailAt {
+				return NewError("TICK_FAILED", "tick failed")
+			}
+			if err := stream.Send(tick{N: i}); err != nil {
+				return err
+			}
+		}
+		if input.FailAt > input.Count {
+			return NewError("TICK_FAILED", "tick failed")
+		}
+		return nil
+	}).WithErrors("TICK_FAILED"))
+	return s
+}
+
+func subscribe(s http.Handler, target, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSubscriptionNDJSON(t *testing.T) {
+	s := newSubscriptionServer(nil)
+
+	tests := []struct {
+		name      string
+		target    string
+		wantCode  int
+		wantCType string
+		wantBody  string
+	}{
+		{
+			"messages", "/api/ticks?count=3", http.StatusOK, contentTypeNDJSON,
+			`{"data":{"n":1}}` + "\n" + `{"data":{"n":2}}` + "\n" + `{"data":{"n":3}}` + "\n",
+		},
+		{"no messages", "/api/ticks?count=0", http.StatusOK, contentTypeNDJSON, ""},
+		{
+			"error after messages", "/api/ticks?count=3&failAt=2", http.StatusOK, contentTypeNDJSON,
+			`{"data":{"n":1}}` + "\n" + `{"error":{"code":"TICK_FAILED","message":"tick failed"}}` + "\n",
+		},
+		{
+			"error before messages", "/api/ticks?count=3&failAt=1", http.StatusBadRequest, "application/json",
+			`{"code":"TICK_FAILED","message":"tick failed"}` + "\n",
+		},
+		{"invalid input", "/api/ticks?count=11", http.StatusBadRequest, "application/json", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := subscribe(s, tt.target, "")
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantCode, rec.Body)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != tt.wantCType {
+				t.Errorf("Content-Type = %q, want %q", ct, tt.wantCType)
+			}
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", rec.Body, tt.wantBody)
+			}
+			if tt.wantCode == http.StatusOK && tt.wantBody != "" && !rec.Flushed {
+				t.Error("stream was never flushed")
+			}
+		})
+	}
+}
+
+func TestSubscriptionSSE(t *testing.T) {
+	s := newSubscriptionServer(nil)
+
+	rec := subscribe(s, "/api/ticks?count=2", "text/event-stream")
+	if ct := rec.Header().Get("Content-Type"); ct != contentTypeSSE {
+		t.Errorf("Content-Type = %q, want %q", ct, contentTypeSSE)
+	}
+	want := "data: {\"n\":1}\n\ndata: {\"n\":2}\n\nevent: end\ndata: null\n\n"
+	if rec.Body.String() != want {
+		t.Errorf("body = %q, want %q", rec.Body, want)
+	}
+
+	rec = subscribe(s, "/api/ticks?count=2&failAt=3", "text/event-stream")
+	if !strings.HasSuffix(rec.Body.String(), "event: error\ndata: {\"code\":\"TICK_FAILED\",\"message\":\"tick failed\"}\n\n") {
+		t.Errorf("body = %q, want a trailing error event", rec.Body)
+	}
+}
+
+func TestSubscriptionSendAfterReturn(t *testing.T) {
+	var leaked *Stream[tick]
+	s := newSubscriptionServer(&leaked)
+	subscribe(s, "/api/ticks?count=1", "")
+
+	if err := leaked.Send(tick{N: 99}); !errors.Is(err, ErrStreamClosed) {
+		t.Errorf("Send() after return = %v, want ErrStreamClosed", err)
+	}
+}
+
+func TestSubscriptionRouting(t *testing.T) {
+	s := newSubscriptionServer(nil)
+	if rec := do(s, http.MethodPost, "/api/ticks", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d, want 405", rec.Code)
+	}
+}
+
+func TestGenerateTypeScriptSubscriptions(t *testing.T) {
+	outPath := filepath.Join(t.TempDir(), testFileName)
+
+	err := GenerateTypeScript(Opts{
+		OutPath:   outPath,
+		RouteDefs: newSubscriptionServer(nil).RouteDefs(),
+		Client:    &ClientOpts{},
+	})
+	if err != nil {
+		t.Fatalf("GenerateTypeScript failed: %s", err)
+	}
+
+	content, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("Failed to read generated TypeScript file: %s", err)
+	}
+	contentStr := normalizeWhiteSpace(string(content))
+
+	for _, expectedStr := range []string{
+		`actionType: "subscription",`,
+		`export type SubscriptionAPIKey = SubscriptionAPIRoute["key"];`,
+		`export type SubscriptionAPIOutput<T extends SubscriptionAPIKey> = Extract<SubscriptionAPIRoute, { key: T }>["phantomOutputType"];`,
+		`export async function* subscribe<K extends SubscriptionAPIKey>(`,
+		`): AsyncGenerator<SubscriptionAPIOutput<K>, void, undefined> {`,
+	} {
+		if !strings.Contains(contentStr, normalizeWhiteSpace(expectedStr)) {
+			t.Errorf("Expected string not found in generated TypeScript content: %s", expectedStr)
+		}
+	}
+}
@@ -2,6 +2,9 @@ package tsgen
 
 import (
 	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
 	"slices"
 	"strings"
 
@@ -19,6 +22,30 @@ type Opts struct {
 	Collection            []CollectionItem
 	CollectionVarName     string // Defaults to "tsgenCollection"
 	ExportCollectionArray bool
+
+	// Optional. If set, a Zod schema is emitted alongside each exported type,
+	// named by tsgencore.ZodSchemaName (e.g. "UserSchema" for "User"), with
+	// validate tags applied as checks and refinements. The generated file
+	// then imports z from "zod".
+	ZodSchemas bool
+
+	// Optional. If set, each enum type (see tsgencore.TSEnumValuesMethodName)
+	// is also emitted as a const object of the same name, keyed by each
+	// value's String() if it implements fmt.Stringer, or else by the value.
+	EnumConstObjects bool
+
+	// Optional. The TypeScript to use for the given types wherever they
+	// appear, in place of what would be derived from their Go definitions.
+	// Useful for third-party types, e.g. uuid.UUID or decimal.Decimal as
+	// "string", or sql.NullString as "string | null". Overridden types are
+	// emitted inline, not as named types (unless passed as AdHocTypes).
+	//
+	// Types with custom JSON marshalling (i.e., that implement json.Marshaler
+	// or encoding.TextMarshaler) should usually have an entry, since their
+	// fields don't reflect what is sent. Without one, they are emitted as
+	// "unknown", or as "string" if they only implement
+	// encoding.TextMarshaler.
+	TypeOverrides map[reflect.Type]string
 }
 
 func GenerateTSContent(opts Opts) (string, error) {
@@ -39,13 +66,27 @@ func GenerateTSContent(opts Opts) (string, error) {
 
 	write(&f, comment("Generated by tsgen. DO NOT EDIT."), 2)
 
+	if opts.ZodSchemas {
+		write(&f, `import { z } from "zod";`, 2)
+	}
+
 	if hasCollection {
 		write(&f, comment("Collection:"), 2)
 		write(&f, collection, 2)
 	}
 
+	exports, err := getExports(merged, opts.EnumConstObjects)
+	if err != nil {
+		return "", err
+	}
+
 	write(&f, comment("Ad Hoc Types:"), 2)
-	write(&f, getExports(merged), 2)
+	write(&f, exports, 2)
+
+	if opts.ZodSchemas {
+		write(&f, comment("Zod Schemas:"), 2)
+		write(&f, getZodExports(merged), 2)
+	}
 
 	extraTSTrimmed := strings.TrimSpace(opts.ExtraTSCode)
 	if extraTSTrimmed != "" {
@@ -105,7 +146,7 @@ func optsToMerged(opts Opts) tsgencore.Results {
 		}
 	}
 
-	return tsgencore.ProcessTypes(adHocTypes)
+	return tsgencore.ProcessTypesWithOpts(adHocTypes, tsgencore.ProcessTypesOpts{TypeOverrides: opts.TypeOverrides})
 }
 
 func getCollectionStr(opts Opts, merged tsgencore.Results) (string, error) {
@@ -199,10 +240,17 @@ func getCollectionStr(opts Opts, merged tsgencore.Results) (string, error) {
 	return strings.TrimSpace(collection.String()), nil
 }
 
-func getExports(merged tsgencore.Results) string {
+func getExports(merged tsgencore.Results, enumConstObjects bool) (string, error) {
 	var exportsLines []string
 
 	for _, t := range merged.Types {
+		if t.ResolvedName != "" && enumConstObjects && t.EnumValues != nil {
+			constObj, err := enumConstObject(t.EnumValues)
+			if err != nil {
+				return "", err
+			}
+			exportsLines = append(exportsLines, "export const "+t.ResolvedName+" = "+constObj+";")
+		}
 		if t.ResolvedName != "" {
 			sb := &strings.Builder{}
 			write(sb, "export type ")
@@ -222,7 +270,56 @@ func getExports(merged tsgencore.Results) string {
 		exports.WriteString("\n\n")
 	}
 
-	return strings.TrimSpace(exports.String())
+	return strings.TrimSpace(exports.String()), nil
+}
+
+var identifierRegex = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+func enumConstObject(values []any) (string, error) {
+	var sb strings.Builder
+	write(&sb, "{", 1)
+	for _, v := range values {
+		key := fmt.Sprint(v)
+		if stringer, ok := v.(fmt.Stringer); ok {
+			key = stringer.String()
+		}
+		if !identifierRegex.MatchString(key) {
+			key, _ = formatJSValue(key)
+		}
+		formattedValue, err := formatJSValue(v)
+		if err != nil {
+			return "", err
+		}
+		write(&sb, "\t"+key+": "+formattedValue+",", 1)
+	}
+	write(&sb, "} as const")
+	return sb.String(), nil
+}
+
+func getZodExports(merged tsgencore.Results) string {
+	var exportsLines []string
+
+	for _, t := range merged.Types {
+		if t.ResolvedName != "" {
+			sb := &strings.Builder{}
+			write(sb, "export const ")
+			write(sb, tsgencore.ZodSchemaName(t.ResolvedName))
+			// Annotated so that recursive schemas type-check, and so that
+			// schemas stay in sync with the exported types. The input is
+			// unknown, since schemas also accept the nulls that encoding/json
+			// sends for nil pointers, slices, and maps.
+			write(sb, ": z.ZodType<")
+			write(sb, t.ResolvedName)
+			write(sb, ", z.ZodTypeDef, unknown> = ")
+			write(sb, t.ZodStr)
+			write(sb, ";")
+			exportsLines = append(exportsLines, sb.String())
+		}
+	}
+
+	slices.Sort(exportsLines)
+
+	return strings.Join(exportsLines, "\n\n")
 }
 
 func formatJSValue(v any) (string, error) {
@@ -1,7 +1,12 @@
 package tsgen
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -1034,3 +1039,425 @@ func TestTypeGeneration(t *testing.T) {
 func normalizeWhiteSpace(s string) string {
 	return strings.Join(strings.Fields(s), " ")
 }
+
+type ZodNullableString string
+
+func (ZodNullableString) TSNullable() {}
+
+type ZodAddress struct {
+	City string `json:"city" validate:"required,max=50"`
+}
+
+type ZodUser struct {
+	Email    string            `json:"email" validate:"required,email"`
+	Age      int               `json:"age" validate:"min=18,max=130"`
+	Score    float64           `json:"score" validate:"gt=0"`
+	Role     string            `json:"role" validate:"oneof=admin 'power user'"`
+	Level    int               `json:"level" validate:"oneof=1 2 3"`
+	Tags     []string          `json:"tags,omitempty" validate:"max=5,dive,min=1"`
+	Nickname ZodNullableString `json:"nickname"`
+	Home     ZodAddress        `json:"home"`
+	Friends  []*ZodUser        `json:"friends"`
+	Meta     map[string]int    `json:"meta"`
+	Custom   string            `json:"custom" ts_type:"\"a\" | \"b\""`
+	Skipped  string            `json:"-"`
+}
+
+// TestZodSchemas tests that Zod schemas mirror the generated types
+func TestZodSchemas(t *testing.T) {
+	opts := Opts{
+		AdHocTypes: []*AdHocType{
+			{TypeInstance: ZodUser{}},
+		},
+		ZodSchemas: true,
+	}
+
+	content, err := GenerateTSContent(opts)
+	if err != nil {
+		t.Fatalf("GenerateTSContent failed: %v", err)
+	}
+
+	assertContains(t, content, `import { z } from "zod";`)
+	assertContains(t, content, `export const ZodAddressSchema: z.ZodType<ZodAddress, z.ZodTypeDef, unknown> = z.object({ city: z.string().min(1).max(50), });`)
+	assertContains(t, content, `export const ZodUserSchema: z.ZodType<ZodUser, z.ZodTypeDef, unknown> = z.object({`)
+	assertContains(t, content, `email: z.string().min(1).email(),`)
+	assertContains(t, content, `age: z.number().int().min(18).max(130),`)
+	assertContains(t, content, `score: z.number().gt(0),`)
+	assertContains(t, content, `role: z.string().refine((v) => ["admin","power user"].includes(v), { message: "must be one of: admin, power user" }),`)
+	assertContains(t, content, `level: z.number().int().refine((v) => [1,2,3].includes(v), { message: "must be one of: 1, 2, 3" }),`)
+	assertContains(t, content, `tags: z.array(z.string().min(1)).max(5).optional(),`)
+	assertContains(t, content, `nickname: z.string().nullable(),`)
+	assertContains(t, content, `home: z.lazy(() => ZodAddressSchema),`)
+	assertContains(t, content, `friends: z.array(z.lazy(() => ZodUserSchema)).nullable().transform((v) => v ?? []),`)
+	assertContains(t, content, `meta: z.record(z.string(), z.number().int()).nullable().transform((v) => v ?? {}),`)
+	assertContains(t, content, `custom: z.custom<"a" | "b">(),`)
+	assertNotContains(t, content, "Skipped")
+
+	without, err := GenerateTSContent(Opts{AdHocTypes: opts.AdHocTypes})
+	if err != nil {
+		t.Fatalf("GenerateTSContent failed: %v", err)
+	}
+	assertNotContains(t, without, "zod")
+}
+
+type ZodWire struct {
+	Age      *int           `json:"age"`
+	Nickname *string        `json:"nickname,omitempty"`
+	Tags     []string       `json:"tags"`
+	Labels   []string       `json:"labels,omitempty"`
+	Meta     map[string]int `json:"meta"`
+	Data     []byte         `json:"data"`
+	Home     *ZodAddress    `json:"home"`
+	Friends  []*ZodWire     `json:"friends"`
+	Count    int            `json:"count"`
+}
+
+// TestZodSchemasParseJSON tests that the schemas accept what encoding/json
+// actually sends (e.g., null for nil pointers, slices, and maps), by parsing
+// it with Zod. Skipped unless node (with type stripping) and zod are
+// available; set NODE_PATH to a node_modules directory that has zod.
+func TestZodSchemasParseJSON(t *testing.T) {
+	content, err := GenerateTSContent(Opts{AdHocTypes: []*AdHocType{{TypeInstance: ZodWire{}}}, ZodSchemas: true})
+	if err != nil {
+		t.Fatalf("GenerateTSContent failed: %v", err)
+	}
+	for _, expected := range []string{
+		"age: z.number().int().nullish().transform((v) => v ?? undefined),",
+		"nickname: z.string().nullish().transform((v) => v ?? undefined),",
+		"tags: z.array(z.string()).nullable().transform((v) => v ?? []),",
+		"labels: z.array(z.string()).optional(),",
+		"meta: z.record(z.string(), z.number().int()).nullable().transform((v) => v ?? {}),",
+		`data: z.string().nullable().transform((v) => v ?? ""),`,
+		"count: z.number().int(),",
+	} {
+		assertContains(t, content, expected)
+	}
+
+	node, err := exec.LookPath("node")
+	if err != nil {
+		t.Skip("node is not installed")
+	}
+	if err := exec.Command(node, "--experimental-strip-types", "-e", "").Run(); err != nil {
+		t.Skip("node does not support type stripping")
+	}
+	dir := t.TempDir()
+	resolve := exec.Command(node, "-p", `require.resolve("zod/package.json")`)
+	resolve.Dir = dir
+	zodPackageJSON, err := resolve.Output()
+	if err != nil {
+		t.Skip("zod is not installed")
+	}
+
+	zero, _ := json.Marshal(ZodWire{})
+	age := 30
+	full, _ := json.Marshal(ZodWire{Age: &age, Tags: []string{"a"}, Data: []byte("hi"), Home: &ZodAddress{City: "Oslo"}, Friends: []*ZodWire{{}}})
+
+	files := map[string]string{
+		"package.json": `{"type": "module"}`,
+		"types.ts":     content,
+		"check.ts": fmt.Sprintf(`import { ZodWireSchema } from "./types.ts";
+for (const input of [%s, %s]) {
+	const result = ZodWireSchema.safeParse(input);
+	if (!result.success) {
+		console.error(JSON.stringify(input), result.error.message);
+		process.exit(1);
+	}
+}
+const parsed = ZodWireSchema.parse(%[1]s);
+if (parsed.age !== undefined || parsed.tags.length !== 0 || parsed.data !== "") {
+	console.error("nulls were not replaced:", JSON.stringify(parsed));
+	process.exit(1);
+}
+`, zero, full),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "node_modules"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Dir(strings.TrimSpace(string(zodPackageJSON))), filepath.Join(dir, "node_modules", "zod")); err != nil {
+		t.Fatal(err)
+	}
+
+	check := exec.Command(node, "--experimental-strip-types", "--no-warnings", "check.ts")
+	check.Dir = dir
+	if out, err := check.CombinedOutput(); err != nil {
+		t.Errorf("zod rejected json.Marshal output: %v\n%s", err, out)
+	}
+}
+
+// TestZodSchemasDuplicateNames tests that Zod schemas follow suffixed type names
+func TestZodSchemasDuplicateNames(t *testing.T) {
+	type Type1 struct {
+		Field1 string
+	}
+	type Type2 struct {
+		Field2 int
+	}
+	type Wrapper struct {
+		Inner Type2
+	}
+
+	opts := Opts{
+		AdHocTypes: []*AdHocType{
+			{TypeInstance: Type1{}, TSTypeName: "Same"},
+			{TypeInstance: Wrapper{}, TSTypeName: "Same"},
+		},
+		ZodSchemas: true,
+	}
+
+	content, err := GenerateTSContent(opts)
+	if err != nil {
+		t.Fatalf("GenerateTSContent failed: %v", err)
+	}
+
+	assertContains(t, content, `export const SameSchema: z.ZodType<Same, z.ZodTypeDef, unknown> = z.object({ Field1: z.string(), });`)
+	assertContains(t, content, `export const Same_2Schema: z.ZodType<Same_2, z.ZodTypeDef, unknown> = z.object({ Inner: z.lazy(() => Type2Schema), });`)
+	assertContains(t, content, `export const Type2Schema: z.ZodType<Type2, z.ZodTypeDef, unknown> = z.object({ Field2: z.number().int(), });`)
+}
+
+type GenericPage[T any] struct {
+	Items []T `json:"items"`
+	Total int `json:"total"`
+}
+
+type GenericPair[K comparable, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
+
+type WithGenerics struct {
+	Addresses GenericPage[ZodAddress]                     `json:"addresses"`
+	Lists     GenericPage[[]*ZodAddress]                  `json:"lists"`
+	Pairs     GenericPage[GenericPair[string, time.Time]] `json:"pairs"`
+}
+
+// TestGenericTypes tests that instantiated generic types get valid, stable names
+func TestGenericTypes(t *testing.T) {
+	opts := Opts{
+		AdHocTypes: []*AdHocType{
+			{TypeInstance: WithGenerics{}},
+			{TypeInstance: GenericPage[int]{}},
+		},
+		ZodSchemas: true,
+	}
+
+	content, err := GenerateTSContent(opts)
+	if err != nil {
+		t.Fatalf("GenerateTSContent failed: %v", err)
+	}
+
+	assertContains(t, content, "export type GenericPage_ZodAddress = { items: Array<ZodAddress>; total: number; };")
+	assertContains(t, content, "export type GenericPage_Array_ZodAddress = { items: Array<Array<ZodAddress>>; total: number; };")
+	assertContains(t, content, "export type GenericPage_GenericPair_string_Time = { items: Array<GenericPair_string_Time>; total: number; };")
+	assertContains(t, content, "export type GenericPair_string_Time = { key: string; value: string; };")
+	assertContains(t, content, "export type GenericPage_int = { items: Array<number>; total: number; };")
+	assertContains(t, content, "addresses: GenericPage_ZodAddress;")
+	assertContains(t, content, "export const GenericPage_ZodAddressSchema: z.ZodType<GenericPage_ZodAddress, z.ZodTypeDef, unknown> =")
+	for _, unsanitized := range []string{"Page[", "Pair[", "github.com"} {
+		assertNotContains(t, content, unsanitized)
+	}
+}
+
+type EnumRole string
+
+const (
+	EnumRoleAdmin  EnumRole = "admin"
+	EnumRoleMember EnumRole = "member"
+	EnumRoleGuest  EnumRole = "power guest"
+)
+
+func (EnumRole) TSEnumValues() []EnumRole {
+	return []EnumRole{EnumRoleAdmin, EnumRoleMember, EnumRoleGuest}
+}
+
+type EnumLevel int
+
+const (
+	EnumLevelLow EnumLevel = iota + 1
+	EnumLevelHigh
+)
+
+func (*EnumLevel) TSEnumValues() []EnumLevel { return []EnumLevel{EnumLevelLow, EnumLevelHigh} }
+
+func (l EnumLevel) String() string {
+	if l == EnumLevelLow {
+		return "Low"
+	}
+	return "High"
+}
+
+type WithEnums struct {
+	Role   EnumRole             `json:"role" validate:"required"`
+	Roles  []EnumRole           `json:"roles"`
+	Level  *EnumLevel           `json:"level"`
+	ByRole map[EnumRole]float64 `json:"byRole"`
+}
+
+// TestEnumTypes tests that types with TSEnumValues are emitted as named unions
+func TestEnumTypes(t *testing.T) {
+	opts := Opts{
+		AdHocTypes: []*AdHocType{
+			{TypeInstance: WithEnums{}},
+		},
+		ZodSchemas:       true,
+		EnumConstObjects: true,
+	}
+
+	content, err := GenerateTSContent(opts)
+	if err != nil {
+		t.Fatalf("GenerateTSContent failed: %v", err)
+	}
+
+	assertContains(t, content, `export type EnumRole = "admin" | "member" | "power guest";`)
+	assertContains(t, content, `export type EnumLevel = 1 | 2;`)
+	assertContains(t, content, `export type WithEnums = { role: EnumRole; roles: Array<EnumRole>; level?: EnumLevel; byRole: Partial<Record<EnumRole, number>>; };`)
+	assertContains(t, content, `export const EnumRole = { admin: "admin", member: "member", "power guest": "power guest", } as const;`)
+	assertContains(t, content, `export const EnumLevel = { Low: 1, High: 2, } as const;`)
+	assertContains(t, content, `export const EnumRoleSchema: z.ZodType<EnumRole, z.ZodTypeDef, unknown> = z.enum(["admin", "member", "power guest"]);`)
+	assertContains(t, content, `export const EnumLevelSchema: z.ZodType<EnumLevel, z.ZodTypeDef, unknown> = z.union([z.literal(1), z.literal(2)]);`)
+	assertContains(t, content, `role: z.lazy(() => EnumRoleSchema),`)
+
+	content, err = GenerateTSContent(Opts{AdHocTypes: []*AdHocType{{TypeInstance: EnumLevelLow}}})
+	if err != nil {
+		t.Fatalf("GenerateTSContent failed: %v", err)
+	}
+	assertContains(t, content, `export type EnumLevel = 1 | 2;`)
+	assertNotContains(t, content, "export const")
+}
+
+type MarshalMoney struct {
+	Cents    int64
+	Currency string
+}
+
+func (m MarshalMoney) MarshalJSON() ([]byte, error) { return []byte(`"1.00 USD"`), nil }
+
+type MarshalLevel int
+
+func (l *MarshalLevel) MarshalText() ([]byte, error) { return []byte("low"), nil }
+
+type MarshalRaw struct{ Inner string }
+
+func (MarshalRaw) MarshalJSON() ([]byte, error) { return []byte("{}"), nil }
+
+type WithMarshalers struct {
+	Price  MarshalMoney            `json:"price"`
+	Prices []MarshalMoney          `json:"prices"`
+	Level  *MarshalLevel           `json:"level"`
+	Raw    MarshalRaw              `json:"raw"`
+	Levels map[string]MarshalLevel `json:"levels"`
+	Data   []byte                  `json:"data"`
+	Bytes  [4]byte                 `json:"bytes"`
+	At     time.Time               `json:"at"`
+}
+
+// TestMarshalerTypes tests that types with custom JSON marshalling aren't
+// emitted from their fields
+func TestMarshalerTypes(t *testing.T) {
+	opts := Opts{
+		AdHocTypes: []*AdHocType{
+			{TypeInstance: WithMarshalers{}},
+		},
+		TypeOverrides: map[reflect.Type]string{
+			reflect.TypeFor[MarshalMoney](): "`${number} ${string}`",
+		},
+		ZodSchemas: true,
+	}
+
+	content, err := GenerateTSContent(opts)
+	if err != nil {
+		t.Fatalf("GenerateTSContent failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"price: `${number} ${string}`;",
+		"prices: Array<`${number} ${string}`>;",
+		"level?: string;",
+		"raw: unknown;",
+		"levels: Record<string, string>;",
+		"data: string;",
+		"bytes: Array<number>;",
+		"at: string;",
+		"price: z.custom<`${number} ${string}`>(),",
+		"level: z.string().nullish().transform((v) => v ?? undefined),",
+		"raw: z.unknown(),",
+		`data: z.string().nullable().transform((v) => v ?? ""),`,
+	} {
+		assertContains(t, content, expected)
+	}
+	for _, unexpected := range []string{"Cents", "Inner", "export type MarshalMoney", "export type MarshalRaw"} {
+		assertNotContains(t, content, unexpected)
+	}
+
+	content, err = GenerateTSContent(Opts{AdHocTypes: []*AdHocType{{TypeInstance: MarshalRaw{}}}})
+	if err != nil {
+		t.Fatalf("GenerateTSContent failed: %v", err)
+	}
+	assertContains(t, content, "export type MarshalRaw = unknown;")
+}
+
+type OverrideUUID [16]byte
+
+type OverrideNullString struct {
+	String string
+	Valid  bool
+}
+
+type OverrideEmail string
+
+type WithOverrides struct {
+	ID       OverrideUUID             `json:"id"`
+	IDs      []OverrideUUID           `json:"ids"`
+	Nickname OverrideNullString       `json:"nickname"`
+	ByID     map[string]*OverrideUUID `json:"byId"`
+	Email    OverrideEmail            `json:"email"`
+	Price    MarshalMoney             `json:"price"`
+	Custom   OverrideUUID             `json:"custom" ts_type:"Brand<string>"`
+}
+
+// TestTypeOverrides tests that TypeOverrides apply to a type wherever it appears
+func TestTypeOverrides(t *testing.T) {
+	opts := Opts{
+		AdHocTypes: []*AdHocType{
+			{TypeInstance: WithOverrides{}},
+		},
+		Collection: []CollectionItem{
+			{
+				ArbitraryProperties: map[string]any{"key": "email"},
+				PhantomTypes: map[string]AdHocType{
+					"phantomOutputType": {TypeInstance: OverrideEmail("")},
+				},
+			},
+		},
+		TypeOverrides: map[reflect.Type]string{
+			reflect.TypeFor[OverrideUUID]():       "string",
+			reflect.TypeFor[OverrideNullString](): "string | null",
+			reflect.TypeFor[OverrideEmail]():      "`${string}@${string}`",
+			reflect.TypeFor[MarshalMoney]():       "number",
+		},
+		ZodSchemas: true,
+	}
+
+	content, err := GenerateTSContent(opts)
+	if err != nil {
+		t.Fatalf("GenerateTSContent failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"export type WithOverrides = { id: string; ids: Array<string>; nickname: string | null; byId: Record<string, string>; email: `${string}@${string}`; price: number; custom: Brand<string>; };",
+		"phantomOutputType: null as unknown as `${string}@${string}`,",
+		"id: z.string(),",
+		"nickname: z.string().nullable(),",
+		"email: z.custom<`${string}@${string}`>(),",
+		"price: z.number(),",
+	} {
+		assertContains(t, content, expected)
+	}
+	for _, unexpected := range []string{"Valid", "export type OverrideNullString", "export type OverrideUUID"} {
+		assertNotContains(t, content, unexpected)
+	}
+}
@@ -10,6 +10,7 @@ type typeCollector struct {
 	types             map[reflect.Type]*typeEntry
 	rootType          reflect.Type
 	rootRequestedName string
+	typeOverrides     map[reflect.Type]string
 }
 
 type typeEntry struct {
@@ -19,11 +20,12 @@ type typeEntry struct {
 	isReferenced   bool
 	visited        bool
 	coreType       string
+	coreZod        string
 	requestedName  string
 }
 
-func newTypeCollector() *typeCollector {
-	return &typeCollector{types: make(map[reflect.Type]*typeEntry)}
+func newTypeCollector(typeOverrides map[reflect.Type]string) *typeCollector {
+	return &typeCollector{types: make(map[reflect.Type]*typeEntry), typeOverrides: typeOverrides}
 }
 
 func (c *typeCollector) getOrCreateEntry(t reflect.Type, userDefinedAlias ...string) *typeEntry {
@@ -41,8 +43,8 @@ func (c *typeCollector) getOrCreateEntry(t reflect.Type, userDefinedAlias ...str
 		entry.requestedName = userDefinedAlias[0]
 	} else {
 		var requestedName string
-		if !isBasicType(t) {
-			requestedName = t.Name()
+		if !c.isBasicType(t) || isEnumType(t) {
+			requestedName = typeName(t)
 		}
 		if requestedName != "" {
 			entry.requestedName = requestedName
@@ -56,6 +58,18 @@ func (c *typeCollector) getOrCreateEntry(t reflect.Type, userDefinedAlias ...str
 func (c *typeCollector) collectType(t reflect.Type, userDefinedAlias ...string) {
 	isRoot := (t == c.rootType)
 
+	if _, ok := c.getOverriddenTypeScriptType(t); ok {
+		// Its definition isn't what gets sent, so there's nothing to collect
+		// beyond the type itself
+		if isRoot {
+			entry := c.getOrCreateEntry(t, userDefinedAlias...)
+			entry.visited = true
+			entry.coreType = c.getTypeScriptType(t)
+			entry.coreZod = c.getZodType(t)
+		}
+		return
+	}
+
 	if t.Name() != "" || isRoot {
 		entry := c.getOrCreateEntry(t, userDefinedAlias...)
 		if entry.visited {
@@ -63,12 +77,17 @@ func (c *typeCollector) collectType(t reflect.Type, userDefinedAlias ...string)
 		}
 		entry.visited = true
 
-		if isBasicType(t) && !isRoot {
-			entry.coreType = c.getTypeScriptType(t)
+		if c.isBasicType(t) && !isRoot {
+			if values := EnumValues(t); values != nil {
+				entry.coreType = enumTSUnion(values)
+				entry.coreZod = enumZod(values)
+			} else {
+				entry.coreType = c.getTypeScriptType(t)
+			}
 			return
 		}
 	} else {
-		if !isRoot && isBasicType(t) {
+		if !isRoot && c.isBasicType(t) {
 			return
 		}
 	}
@@ -185,6 +204,11 @@ func (c *typeCollector) collectFieldType(t reflect.Type) {
 			entry.isReferenced = true
 		}
 		c.collectType(valueType)
+
+	default:
+		if isEnumType(t) {
+			c.collectType(t)
+		}
 	}
 }
 
@@ -209,7 +233,9 @@ func (c *typeCollector) buildDefinitions() (_results, IDStr) {
 				OriginalName: c.rootRequestedName,
 				ResolvedName: c.types[c.rootType].resolvedName,
 				ReflectType:  c.rootType,
+				EnumValues:   EnumValues(c.rootType),
 				TSStr:        c.getTypeScriptType(c.rootType),
+				ZodStr:       c.getZodType(c.rootType),
 			}}
 
 			return results, id
@@ -225,6 +251,13 @@ func (c *typeCollector) buildDefinitions() (_results, IDStr) {
 				entry.coreType = c.getTypeScriptType(t)
 			}
 		}
+		if entry.coreZod == "" {
+			if t.Kind() == reflect.Struct {
+				entry.coreZod = buildZodObj(c.generateZodFields(t))
+			} else {
+				entry.coreZod = c.getZodType(t)
+			}
+		}
 	}
 
 	reflectTypeToID := make(map[reflect.Type]IDStr)
@@ -235,6 +268,9 @@ func (c *typeCollector) buildDefinitions() (_results, IDStr) {
 				continue
 			}
 		}
+		if _, ok := c.getOverriddenTypeScriptType(t); ok && t != c.rootType {
+			continue
+		}
 
 		requestedName := entry.requestedName
 		if t == c.rootType && c.rootRequestedName != "" {
@@ -263,7 +299,9 @@ func (c *typeCollector) buildDefinitions() (_results, IDStr) {
 			OriginalName: requestedName,
 			ResolvedName: entry.resolvedName,
 			ReflectType:  t,
+			EnumValues:   EnumValues(t),
 			TSStr:        c.types[t].coreType,
+			ZodStr:       c.types[t].coreZod,
 		}
 	}
 
@@ -287,7 +325,7 @@ func (c *typeCollector) generateTypeFields(t reflect.Type) []string {
 		if field.PkgPath != "" {
 			continue
 		}
-		if shouldOmitField(field) {
+		if ShouldOmitField(field) {
 			continue
 		}
 
@@ -295,16 +333,16 @@ func (c *typeCollector) generateTypeFields(t reflect.Type) []string {
 			if field.Type.Kind() == reflect.Struct {
 				for j := 0; j < field.Type.NumField(); j++ {
 					embField := field.Type.Field(j)
-					if embField.PkgPath != "" || shouldOmitField(embField) {
+					if embField.PkgPath != "" || ShouldOmitField(embField) {
 						continue
 					}
 
-					fieldName := getJSONFieldName(embField)
+					fieldName := JSONFieldName(embField)
 					if fieldName == "" {
 						continue
 					}
 
-					customType := getCustomTypeScriptType(embField)
+					customType := CustomTypeScriptType(embField)
 					var fieldType string
 					if customType != "" {
 						fieldType = customType
@@ -312,7 +350,7 @@ func (c *typeCollector) generateTypeFields(t reflect.Type) []string {
 						fieldType = c.getTypeScriptType(embField.Type)
 					}
 
-					if isOptionalField(embField) {
+					if IsOptionalField(embField) {
 						fields = append(fields, fmt.Sprintf("%s?: %s", fieldName, fieldType))
 					} else {
 						fields = append(fields, fmt.Sprintf("%s: %s", fieldName, fieldType))
@@ -321,9 +359,9 @@ func (c *typeCollector) generateTypeFields(t reflect.Type) []string {
 				continue
 			} else if field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct {
 				ptrType := field.Type.Elem()
-				structName := ptrType.Name()
+				structName := typeName(ptrType)
 
-				fieldName := getJSONFieldName(field)
+				fieldName := JSONFieldName(field)
 				if fieldName == "" {
 					fieldName = structName
 				}
@@ -334,12 +372,12 @@ func (c *typeCollector) generateTypeFields(t reflect.Type) []string {
 			}
 		}
 
-		fieldName := getJSONFieldName(field)
+		fieldName := JSONFieldName(field)
 		if fieldName == "" {
 			continue
 		}
 
-		customType := getCustomTypeScriptType(field)
+		customType := CustomTypeScriptType(field)
 		var fieldType string
 		if customType != "" {
 			fieldType = customType
@@ -351,7 +389,7 @@ func (c *typeCollector) generateTypeFields(t reflect.Type) []string {
 			}
 		}
 
-		if isOptionalField(field) {
+		if IsOptionalField(field) {
 			fields = append(fields, fmt.Sprintf("%s?: %s", fieldName, fieldType))
 		} else {
 			fields = append(fields, fmt.Sprintf("%s: %s", fieldName, fieldType))
@@ -385,6 +423,22 @@ func (c *typeCollector) getTypeScriptType(t reflect.Type) string {
 		return "undefined"
 	}
 
+	typeStr, ok := c.getOverriddenTypeScriptType(t)
+	if !ok {
+		typeStr = c.getTypeScriptBaseType(t)
+	}
+
+	if IsMarkedNullable(t) {
+		typeStr = fmt.Sprintf("%s | null", typeStr)
+	}
+	if IsMarkedOptional(t) {
+		typeStr = fmt.Sprintf("%s | undefined", typeStr)
+	}
+
+	return typeStr
+}
+
+func (c *typeCollector) getTypeScriptBaseType(t reflect.Type) string {
 	var typeStr string
 
 	switch t.Kind() {
@@ -397,15 +451,19 @@ func (c *typeCollector) getTypeScriptType(t reflect.Type) string {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
 		reflect.Float32, reflect.Float64:
-		typeStr = "number"
+		typeStr = c.enumOr(t, "number")
 
 	case reflect.String:
-		typeStr = "string"
+		typeStr = c.enumOr(t, "string")
 
 	case reflect.Ptr:
 		typeStr = c.getTypeScriptType(t.Elem())
 
 	case reflect.Slice, reflect.Array:
+		if IsByteSlice(t) {
+			typeStr = "string"
+			break
+		}
 		elemType := c.getTypeScriptType(t.Elem())
 		typeStr = fmt.Sprintf("Array<%s>", elemType)
 
@@ -413,6 +471,10 @@ func (c *typeCollector) getTypeScriptType(t reflect.Type) string {
 		keyType := c.getTypeScriptType(t.Key())
 		valueType := c.getTypeScriptType(t.Elem())
 		typeStr = fmt.Sprintf("Record<%s, %s>", keyType, valueType)
+		if isEnumType(t.Key()) {
+			// A map needn't have every key
+			typeStr = fmt.Sprintf("Partial<%s>", typeStr)
+		}
 
 	case reflect.Struct:
 		switch {
@@ -439,12 +501,5 @@ func (c *typeCollector) getTypeScriptType(t reflect.Type) string {
 		typeStr = "unknown"
 	}
 
-	if IsMarkedNullable(t) {
-		typeStr = fmt.Sprintf("%s | null", typeStr)
-	}
-	if IsMarkedOptional(t) {
-		typeStr = fmt.Sprintf("%s | undefined", typeStr)
-	}
-
 	return typeStr
 }
@@ -31,9 +31,12 @@ type TypeInfo struct {
 	OriginalName string
 	ResolvedName string
 	ReflectType  reflect.Type
+	EnumValues   []any // The values of an enum type (see TSEnumValuesMethodName), or nil
 	TSStr        string
+	ZodStr       string // Zod schema expression, referencing other types' schemas by ZodSchemaName
 
-	_id IDStr
+	_id         IDStr
+	_overridden bool
 }
 
 var _any any
@@ -41,7 +44,7 @@ var _undefined_id = getID(&AdHocType{TypeInstance: nil})
 var _unknown_id = getID(&AdHocType{TypeInstance: &_any}) // ptr intentional to get interface {}
 func (t *TypeInfo) IsTSUndefined() bool                  { return t._id == _undefined_id }
 func (t *TypeInfo) IsTSUnknown() bool                    { return t._id == _unknown_id }
-func (t *TypeInfo) IsTSBasicType() bool                  { return isBasicType(t.ReflectType) }
+func (t *TypeInfo) IsTSBasicType() bool                  { return t._overridden || isBasicType(t.ReflectType) }
 
 func getEffectiveReflectType(instance any) reflect.Type {
 	t := reflect.TypeOf(instance)
@@ -60,8 +63,8 @@ func getEffectiveRequestedName(t reflect.Type, requestedName string) string {
 
 func getNaturalName(t reflect.Type) string {
 	if t != nil {
-		n := t.Name()
-		if n != "" && isBasicType(t) {
+		n := typeName(t)
+		if n != "" && isBasicType(t) && !isEnumType(t) {
 			return ""
 		}
 		return n
@@ -69,7 +72,7 @@ func getNaturalName(t reflect.Type) string {
 	return ""
 }
 
-func traverseType(adHocType *AdHocType) (_results, IDStr) {
+func traverseType(adHocType *AdHocType, typeOverrides map[reflect.Type]string) (_results, IDStr) {
 	if adHocType == nil || adHocType.TypeInstance == nil {
 		return _results{}, ""
 	}
@@ -81,7 +84,7 @@ func traverseType(adHocType *AdHocType) (_results, IDStr) {
 		return _results{}, ""
 	}
 
-	c := newTypeCollector()
+	c := newTypeCollector(typeOverrides)
 	c.rootType = t
 	c.rootRequestedName = effectiveRequestedName
 
@@ -92,6 +95,8 @@ func traverseType(adHocType *AdHocType) (_results, IDStr) {
 type Results struct {
 	Types     []*TypeInfo
 	id_to_idx map[IDStr]int
+
+	typeOverrides map[reflect.Type]string
 }
 
 func (m *Results) GetTypeInfo(adHocType *AdHocType) *TypeInfo {
@@ -104,6 +109,15 @@ func (m *Results) GetTypeInfo(adHocType *AdHocType) *TypeInfo {
 	// If we don't, you're probably looking for a basic type,
 	// which will fall back to "unknown" if not
 	reflectType := getEffectiveReflectType(adHocType.TypeInstance)
+	if override, ok := m.typeOverrides[reflectType]; ok {
+		return &TypeInfo{
+			_id:          id,
+			_overridden:  true,
+			OriginalName: adHocType.TSTypeName,
+			ReflectType:  reflectType,
+			TSStr:        override,
+		}
+	}
 	return &TypeInfo{
 		_id:          id,
 		OriginalName: adHocType.TSTypeName,
@@ -176,6 +190,12 @@ func mergeTypeResults(results ..._results) Results {
 			}
 			panic("tsgencore error: could not find resolved name to replace matched id: " + id)
 		})
+		finalTypes[i].ZodStr = idRegex.ReplaceAllStringFunc(typeInfo.ZodStr, func(id string) string {
+			if idx, ok := id_to_idx[id]; ok {
+				return ZodSchemaName(finalTypes[idx].ResolvedName)
+			}
+			panic("tsgencore error: could not find resolved name to replace matched id: " + id)
+		})
 	}
 
 	return Results{
@@ -193,15 +213,29 @@ type AdHocType = struct {
 	TSTypeName string
 }
 
+// ProcessTypes collects and names the types, and everything they reference.
 func ProcessTypes(adHocTypes []*AdHocType) Results {
+	return ProcessTypesWithOpts(adHocTypes, ProcessTypesOpts{})
+}
+
+type ProcessTypesOpts struct {
+	// Optional. Maps types to the TypeScript to use for them wherever they
+	// appear, in place of what would be derived from their Go definitions.
+	TypeOverrides map[reflect.Type]string
+}
+
+// ProcessTypesWithOpts is like ProcessTypes, but with options.
+func ProcessTypesWithOpts(adHocTypes []*AdHocType, opts ProcessTypesOpts) Results {
 	types := make([]_results, 0, len(adHocTypes))
 
 	for _, adHocType := range adHocTypes {
-		result, _ := traverseType(adHocType)
+		result, _ := traverseType(adHocType, opts.TypeOverrides)
 		types = append(types, result)
 	}
 
-	return mergeTypeResults(types...)
+	merged := mergeTypeResults(types...)
+	merged.typeOverrides = opts.TypeOverrides
+	return merged
 }
 
 func getID(adHocType *AdHocType) IDStr {
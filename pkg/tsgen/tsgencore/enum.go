@@ -0,0 +1,126 @@
+package tsgencore
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// If you want a named string or integer type to be generated as a union of
+// its values (e.g. "admin" | "member") rather than as string or number, add
+// a "TSEnumValues() []T" method to it, where T is the type itself. The method
+// is called on the zero value (or a pointer to it), so it should return a
+// package-level list:
+//
+//	type Role string
+//
+//	const (
+//		RoleAdmin  Role = "admin"
+//		RoleMember Role = "member"
+//	)
+//
+//	func (Role) TSEnumValues() []Role { return []Role{RoleAdmin, RoleMember} }
+//
+// Enum types are always generated as named types, wherever they are used.
+
+const TSEnumValuesMethodName = "TSEnumValues"
+
+func isEnumType(t reflect.Type) bool {
+	return EnumValues(t) != nil
+}
+
+// EnumValues returns the values of enum type t, or nil if t is not an enum
+// type.
+func EnumValues(t reflect.Type) []any {
+	if t == nil || t.Name() == "" {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	default:
+		return nil
+	}
+
+	method := reflect.New(t).MethodByName(TSEnumValuesMethodName)
+	if !method.IsValid() {
+		return nil
+	}
+	methodType := method.Type()
+	if methodType.NumIn() != 0 || methodType.NumOut() != 1 || methodType.Out(0) != reflect.SliceOf(t) {
+		return nil
+	}
+
+	list := method.Call(nil)[0]
+	values := make([]any, list.Len())
+	for i := range values {
+		values[i] = list.Index(i).Interface()
+	}
+	return values
+}
+
+// enumOr returns the TypeScript for t if it is an enum type: a reference to
+// its named type, or the union itself if t is the root type. Otherwise, it
+// returns fallback.
+func (c *typeCollector) enumOr(t reflect.Type, fallback string) string {
+	values := EnumValues(t)
+	if values == nil {
+		return fallback
+	}
+	if t != c.rootType && c.types[t] != nil {
+		// ID will be replaced later with the correct resolved name
+		return getIDFromReflectType(t, c.types[t].requestedName)
+	}
+	return enumTSUnion(values)
+}
+
+// zodEnumOr is the Zod equivalent of enumOr.
+func (c *typeCollector) zodEnumOr(t reflect.Type, fallback string) string {
+	values := EnumValues(t)
+	if values == nil {
+		return fallback
+	}
+	if t != c.rootType && c.types[t] != nil {
+		// ID will be replaced later with the correct schema name
+		return fmt.Sprintf("z.lazy(() => %s)", getIDFromReflectType(t, c.types[t].requestedName))
+	}
+	return enumZod(values)
+}
+
+func enumTSUnion(values []any) string {
+	if len(values) == 0 {
+		return "never"
+	}
+	return strings.Join(enumLiterals(values), " | ")
+}
+
+func enumZod(values []any) string {
+	literals := enumLiterals(values)
+	switch {
+	case len(literals) == 0:
+		return "z.never()"
+	case reflect.TypeOf(values[0]).Kind() == reflect.String:
+		return fmt.Sprintf("z.enum([%s])", strings.Join(literals, ", "))
+	case len(literals) == 1:
+		return fmt.Sprintf("z.literal(%s)", literals[0])
+	default:
+		for i, literal := range literals {
+			literals[i] = fmt.Sprintf("z.literal(%s)", literal)
+		}
+		return fmt.Sprintf("z.union([%s])", strings.Join(literals, ", "))
+	}
+}
+
+func enumLiterals(values []any) []string {
+	literals := make([]string, len(values))
+	for i, v := range values {
+		b, err := json.Marshal(v)
+		if err != nil {
+			panic(fmt.Sprintf("tsgencore error: could not marshal enum value %v: %s", v, err))
+		}
+		literals[i] = string(b)
+	}
+	return literals
+}
@@ -0,0 +1,45 @@
+package tsgencore
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+var (
+	jsonMarshalerReflectType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerReflectType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// MarshaledTypeScriptType returns the TypeScript for a type whose JSON
+// isn't derived from its fields, because it (or a pointer to it) implements
+// json.Marshaler or encoding.TextMarshaler: "unknown" for a json.Marshaler,
+// and "string" for an encoding.TextMarshaler. The bool is false for other
+// types.
+func MarshaledTypeScriptType(t reflect.Type) (string, bool) {
+	// time.Time's wire format is known, and enum values are marshaled as
+	// they are listed
+	if t == nil || t.Kind() == reflect.Interface || t.Kind() == reflect.Ptr || t == reflect.TypeOf(time.Time{}) || isEnumType(t) {
+		return "", false
+	}
+
+	ptr := reflect.PointerTo(t)
+	isJSONMarshaler := t.Implements(jsonMarshalerReflectType) || ptr.Implements(jsonMarshalerReflectType)
+	isTextMarshaler := t.Implements(textMarshalerReflectType) || ptr.Implements(textMarshalerReflectType)
+	if !isJSONMarshaler && !isTextMarshaler {
+		return "", false
+	}
+
+	if isJSONMarshaler {
+		return "unknown", true
+	}
+	return "string", true
+}
+
+// IsByteSlice reports whether t is sent as a base64 string by encoding/json.
+func IsByteSlice(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 &&
+		!reflect.PointerTo(t.Elem()).Implements(jsonMarshalerReflectType) &&
+		!reflect.PointerTo(t.Elem()).Implements(textMarshalerReflectType)
+}
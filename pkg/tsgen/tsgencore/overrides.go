@@ -0,0 +1,59 @@
+package tsgencore
+
+import (
+	"reflect"
+	"strings"
+)
+
+// getOverriddenTypeScriptType returns the TypeScript for a type that isn't
+// derived from its Go definition: its entry in typeOverrides if it has one,
+// or else the TypeScript for its custom JSON marshalling, if any. The bool is
+// false for other types.
+func (c *typeCollector) getOverriddenTypeScriptType(t reflect.Type) (string, bool) {
+	if override, ok := c.typeOverrides[t]; ok {
+		return override, true
+	}
+	return MarshaledTypeScriptType(t)
+}
+
+// isBasicType is like the package-level isBasicType, but also counts types
+// with an override as basic, so that they are emitted inline and nothing
+// they reference is collected.
+func (c *typeCollector) isBasicType(t reflect.Type) bool {
+	if _, ok := c.getOverriddenTypeScriptType(t); ok {
+		return true
+	}
+	return isBasicType(t)
+}
+
+// zodForTypeScript returns a Zod schema for TypeScript that wasn't derived
+// from Go types. Primitives, and unions of them, get the matching schema.
+// Anything else is only checked statically.
+func zodForTypeScript(tsType string) string {
+	var schemas []string
+	nullable := false
+	for part := range strings.SplitSeq(tsType, "|") {
+		switch part = strings.TrimSpace(part); part {
+		case "null":
+			nullable = true
+		case "string", "number", "boolean", "unknown", "undefined":
+			schemas = append(schemas, "z."+part+"()")
+		default:
+			return "z.custom<" + tsType + ">()"
+		}
+	}
+
+	var schema string
+	switch len(schemas) {
+	case 0:
+		return "z.null()"
+	case 1:
+		schema = schemas[0]
+	default:
+		schema = "z.union([" + strings.Join(schemas, ", ") + "])"
+	}
+	if nullable {
+		schema += ".nullable()"
+	}
+	return schema
+}
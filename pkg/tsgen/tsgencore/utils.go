@@ -2,10 +2,42 @@ package tsgencore
 
 import (
 	"reflect"
+	"regexp"
 	"strings"
 	"time"
 )
 
+// typeName returns t's name as a valid TypeScript identifier. Names of
+// instantiated generic types, such as "Page[github.com/x/models.User]", are
+// flattened to their type arguments' unqualified names, with slices and
+// arrays as "Array" (e.g. "Page_User", or "Page_Array_User" for
+// Page[[]*models.User]). Any clash that results is resolved like any other
+// duplicate name.
+func typeName(t reflect.Type) string {
+	name := t.Name()
+	open := strings.IndexByte(name, '[')
+	if open < 0 || !strings.HasSuffix(name, "]") {
+		return name
+	}
+
+	parts := []string{name[:open]}
+	for _, token := range genericArgTokenRegex.FindAllString(name[open+1:len(name)-1], -1) {
+		if token[0] == '[' {
+			parts = append(parts, "Array")
+			continue
+		}
+		if i := strings.LastIndexByte(token, '.'); i >= 0 {
+			token = token[i+1:]
+		}
+		if token = strings.ReplaceAll(token, "-", "_"); token != "" {
+			parts = append(parts, token)
+		}
+	}
+	return strings.Join(parts, "_")
+}
+
+var genericArgTokenRegex = regexp.MustCompile(`\[\d*\]|[\p{L}\p{N}_./-]+`)
+
 func isBasicType(t reflect.Type) bool {
 	if t == nil {
 		return false
@@ -32,7 +64,9 @@ func isUnexported(field reflect.StructField) bool {
 	return field.PkgPath != ""
 }
 
-func isOptionalField(field reflect.StructField) bool {
+// IsOptionalField reports whether the field is optional ("?:") in the
+// generated TypeScript: pointers and fields tagged omitempty or omitzero.
+func IsOptionalField(field reflect.StructField) bool {
 	if field.Type.Kind() == reflect.Ptr {
 		return true
 	}
@@ -48,7 +82,8 @@ func isOptionalField(field reflect.StructField) bool {
 	return false
 }
 
-func getJSONFieldName(field reflect.StructField) string {
+// JSONFieldName returns the field's name in JSON, or "" if it is skipped.
+func JSONFieldName(field reflect.StructField) string {
 	tag := field.Tag.Get("json")
 	if tag == "" {
 		return field.Name
@@ -63,12 +98,14 @@ func getJSONFieldName(field reflect.StructField) string {
 	return field.Name
 }
 
-func shouldOmitField(field reflect.StructField) bool {
+// ShouldOmitField reports whether the field is tagged json:"-".
+func ShouldOmitField(field reflect.StructField) bool {
 	tag := field.Tag.Get("json")
 	return tag == "-" || strings.HasPrefix(tag, "-,")
 }
 
-func getCustomTypeScriptType(field reflect.StructField) string {
+// CustomTypeScriptType returns the field's ts_type tag override, if any.
+func CustomTypeScriptType(field reflect.StructField) string {
 	return field.Tag.Get("ts_type")
 }
 
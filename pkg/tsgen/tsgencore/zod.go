@@ -0,0 +1,318 @@
+package tsgencore
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ZodSchemaName returns the name of the Zod schema generated for the type
+// with the given resolved name.
+func ZodSchemaName(resolvedName string) string {
+	return resolvedName + "Schema"
+}
+
+// getZodType mirrors getTypeScriptType. References to named structs are lazy,
+// so schemas can be declared in any order and may be recursive.
+func (c *typeCollector) getZodType(t reflect.Type) string {
+	if t == nil {
+		return "z.undefined()"
+	}
+	return c.getZodBaseType(t) + zodMarkers(t)
+}
+
+func (c *typeCollector) getZodBaseType(t reflect.Type) string {
+	if tsType, ok := c.getOverriddenTypeScriptType(t); ok {
+		return zodForTypeScript(tsType)
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return "z.unknown()"
+
+	case reflect.Bool:
+		return "z.boolean()"
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return c.zodEnumOr(t, "z.number().int()")
+
+	case reflect.Float32, reflect.Float64:
+		return "z.number()"
+
+	case reflect.String:
+		return c.zodEnumOr(t, "z.string()")
+
+	case reflect.Ptr:
+		return c.getZodType(t.Elem())
+
+	case reflect.Slice, reflect.Array:
+		if IsByteSlice(t) {
+			return "z.string()"
+		}
+		return fmt.Sprintf("z.array(%s)", c.getZodType(t.Elem()))
+
+	case reflect.Map:
+		// JSON object keys are always strings
+		return fmt.Sprintf("z.record(z.string(), %s)", c.getZodType(t.Elem()))
+
+	case reflect.Struct:
+		switch {
+		case t == reflect.TypeOf(time.Time{}):
+			return "z.string()"
+		case t == reflect.TypeOf(time.Duration(0)):
+			return "z.number().int()"
+		case t.Name() != "" && c.types[t] != nil:
+			entry := c.getOrCreateEntry(t)
+			requestedName := entry.requestedName
+
+			if t == c.rootType && c.rootRequestedName != "" {
+				requestedName = c.rootRequestedName
+			}
+
+			// ID will be replaced later with the correct schema name
+			return fmt.Sprintf("z.lazy(() => %s)", getIDFromReflectType(t, requestedName))
+		default:
+			return buildZodObj(c.generateZodFields(t))
+		}
+
+	default:
+		return "z.unknown()"
+	}
+}
+
+func zodMarkers(t reflect.Type) string {
+	var markers string
+	if IsMarkedNullable(t) {
+		markers += ".nullable()"
+	}
+	if IsMarkedOptional(t) {
+		markers += ".optional()"
+	}
+	return markers
+}
+
+// generateZodFields mirrors generateTypeFields.
+func (c *typeCollector) generateZodFields(t reflect.Type) []string {
+	var fields []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.PkgPath != "" || ShouldOmitField(field) {
+			continue
+		}
+
+		if field.Anonymous {
+			if field.Type.Kind() == reflect.Struct {
+				for j := 0; j < field.Type.NumField(); j++ {
+					embField := field.Type.Field(j)
+					if embField.PkgPath != "" || ShouldOmitField(embField) {
+						continue
+					}
+					if fieldName := JSONFieldName(embField); fieldName != "" {
+						fields = append(fields, fmt.Sprintf("%s: %s", fieldName, c.getZodFieldType(embField)))
+					}
+				}
+				continue
+			} else if field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct {
+				fieldName := JSONFieldName(field)
+				if fieldName == "" {
+					fieldName = typeName(field.Type.Elem())
+				}
+				fields = append(fields, fmt.Sprintf("%s: %s.optional()", fieldName, c.getZodType(field.Type.Elem())))
+				continue
+			}
+		}
+
+		if fieldName := JSONFieldName(field); fieldName != "" {
+			fields = append(fields, fmt.Sprintf("%s: %s", fieldName, c.getZodFieldType(field)))
+		}
+	}
+
+	return fields
+}
+
+// getZodFieldType returns the field's schema, refined by its validate tag.
+// Rules after "dive" refine the elements of a slice, array, or map.
+func (c *typeCollector) getZodFieldType(field reflect.StructField) string {
+	var schema string
+
+	if customType := CustomTypeScriptType(field); customType != "" {
+		schema = fmt.Sprintf("z.custom<%s>()", customType)
+	} else {
+		t := field.Type
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		rules := strings.Split(field.Tag.Get("validate"), ",")
+		_, overridden := c.getOverriddenTypeScriptType(t)
+		if overridden {
+			rules = nil // the schema may not be the one the rules expect
+		}
+		var elemRules []string
+		for i, rule := range rules {
+			if rule == "dive" {
+				rules, elemRules = rules[:i], rules[i+1:]
+				break
+			}
+		}
+
+		switch {
+		case len(elemRules) > 0 && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+			elem := withZodRules(c.getZodBaseType(t.Elem()), t.Elem(), elemRules) + zodMarkers(t.Elem())
+			schema = fmt.Sprintf("z.array(%s)", elem)
+		case len(elemRules) > 0 && t.Kind() == reflect.Map:
+			elem := withZodRules(c.getZodBaseType(t.Elem()), t.Elem(), elemRules) + zodMarkers(t.Elem())
+			schema = fmt.Sprintf("z.record(z.string(), %s)", elem)
+		default:
+			schema = c.getZodBaseType(t)
+		}
+		schema = withZodRules(schema, t, rules) + zodMarkers(t)
+
+		// encoding/json sends nil pointers, slices, and maps as null. Accept
+		// that, and parse it as what the generated type expects instead.
+		switch {
+		case field.Type.Kind() == reflect.Ptr:
+			return schema + ".nullish().transform((v) => v ?? undefined)"
+		case overridden || IsOptionalField(field):
+		case IsByteSlice(t):
+			schema += `.nullable().transform((v) => v ?? "")`
+		case t.Kind() == reflect.Slice:
+			schema += ".nullable().transform((v) => v ?? [])"
+		case t.Kind() == reflect.Map:
+			schema += ".nullable().transform((v) => v ?? {})"
+		}
+	}
+
+	if IsOptionalField(field) {
+		schema += ".optional()"
+	}
+	return schema
+}
+
+// withZodRules appends the Zod equivalents of validate rules to schema.
+// Checks that Zod has built in (e.g. min, max, email) come first, followed
+// by refinements, since the latter return a schema without those methods.
+// Rules with no equivalent, and alternatives ("a|b"), are skipped.
+func withZodRules(schema string, t reflect.Type, rules []string) string {
+	if isEnumType(t) {
+		return schema // already constrained to its values
+	}
+
+	isString := t.Kind() == reflect.String
+	isList := t.Kind() == reflect.Slice || t.Kind() == reflect.Array
+	isNumber := false
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		isNumber = true
+	}
+
+	var checks, refinements strings.Builder
+
+	for _, rule := range rules {
+		if strings.Contains(rule, "|") {
+			continue
+		}
+		name, param, _ := strings.Cut(rule, "=")
+		if param != "" && name != "oneof" {
+			if _, err := strconv.ParseFloat(param, 64); err != nil {
+				continue
+			}
+		}
+
+		switch name {
+		case "required":
+			switch {
+			case isString:
+				checks.WriteString(".min(1)")
+			case isNumber:
+				refinements.WriteString(`.refine((v) => v !== 0, { message: "required" })`)
+			case t.Kind() == reflect.Bool:
+				refinements.WriteString(`.refine((v) => v, { message: "required" })`)
+			}
+		case "min", "gte", "max", "lte":
+			if param != "" && (isString || isList || isNumber) {
+				method := "min"
+				if name == "max" || name == "lte" {
+					method = "max"
+				}
+				fmt.Fprintf(&checks, ".%s(%s)", method, param)
+			}
+		case "gt", "lt":
+			if param != "" && isNumber {
+				fmt.Fprintf(&checks, ".%s(%s)", name, param)
+			}
+		case "len":
+			if param != "" && (isString || isList) {
+				fmt.Fprintf(&checks, ".length(%s)", param)
+			}
+		case "email", "url", "uuid":
+			if isString {
+				fmt.Fprintf(&checks, ".%s()", name)
+			}
+		case "oneof":
+			values := ValidateOneOfValues(param)
+			var list []byte
+			switch {
+			case isString:
+				list, _ = json.Marshal(values)
+			case isNumber:
+				numbers := make([]float64, 0, len(values))
+				for _, v := range values {
+					if n, err := strconv.ParseFloat(v, 64); err == nil {
+						numbers = append(numbers, n)
+					}
+				}
+				list, _ = json.Marshal(numbers)
+			default:
+				continue
+			}
+			msg, _ := json.Marshal("must be one of: " + strings.Join(values, ", "))
+			fmt.Fprintf(&refinements, ".refine((v) => %s.includes(v), { message: %s })", list, msg)
+		}
+	}
+
+	return schema + checks.String() + refinements.String()
+}
+
+// ValidateOneOfValues parses the param of a validate "oneof" rule the way
+// the validator does: values are space-separated, and may be single-quoted
+// to include spaces.
+func ValidateOneOfValues(param string) []string {
+	var values []string
+	for param = strings.TrimSpace(param); param != ""; param = strings.TrimSpace(param) {
+		if param[0] == '\'' {
+			if end := strings.IndexByte(param[1:], '\''); end >= 0 {
+				values = append(values, param[1:end+1])
+				param = param[end+2:]
+				continue
+			}
+		}
+		value, rest, _ := strings.Cut(param, " ")
+		values = append(values, value)
+		param = rest
+	}
+	return values
+}
+
+func buildZodObj(fields []string) string {
+	if len(fields) == 0 {
+		return "z.object({})"
+	}
+	var sb strings.Builder
+	sb.WriteString("z.object({\n")
+	for _, field := range fields {
+		sb.WriteString("\t")
+		sb.WriteString(field)
+		sb.WriteString(",\n")
+	}
+	sb.WriteString("})")
+	return sb.String()
+}
@@ -47,7 +47,11 @@ func setNestedField(v reflect.Value, values map[string][]string) error {
 			continue
 		}
 
-		tag := field.Tag.Get("json")
+		// Keys use the field's JSON name, without options like omitempty
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tag == "-" {
+			continue
+		}
 		if tag == "" {
 			tag = field.Name
 		}
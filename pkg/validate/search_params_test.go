@@ -81,6 +81,25 @@ func TestURLSearchParamsInto(t *testing.T) {
 				return d.Name == "Alice" && d.Age == 25 && reflect.DeepEqual(d.Scores, []int{90, 85, 95})
 			},
 		},
+		{
+			name: "JSON tag options",
+			url:  "http://example.com?q=hello&page=2&-=x",
+			dest: func() any {
+				return &struct {
+					Q       string `json:"q,omitempty"`
+					Page    int    `json:"page,omitzero"`
+					Skipped string `json:"-"`
+				}{}
+			},
+			check: func(i any) bool {
+				d := i.(*struct {
+					Q       string `json:"q,omitempty"`
+					Page    int    `json:"page,omitzero"`
+					Skipped string `json:"-"`
+				})
+				return d.Q == "hello" && d.Page == 2 && d.Skipped == ""
+			},
+		},
 		{
 			name: "Validation failure",
 			url:  "http://example.com?email=invalid-email",